@@ -1,52 +1,357 @@
 package googledrive2hugo
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"net"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
-	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
 )
 
-// all of this is from google drive quickstart
-// likely to be deleted or redone
+// Environment variables used if no option is given
+const (
+	EnvCredentials    = "GDOC_CREDENTIALS"
+	EnvServiceAccount = "GDOC_SERVICE_ACCOUNT"
+	EnvToken          = "GDOC_TOKEN"
+)
+
+// how long to wait for the user to authorize in the browser
+const authTimeout = 5 * time.Minute
+
+type authConfig struct {
+	credentials    string
+	serviceAccount string
+	tokenFile      string
+	scopes         []string
+}
+
+// AuthOption changes how Setup authorizes with Google
+type AuthOption func(*authConfig)
+
+// WithCredentials uses an OAuth client credentials file, as
+// downloaded from the Google API console.  The user is asked to
+// authorize in a browser the first time.  Defaults to $GDOC_CREDENTIALS
+// or "credentials.json".
+func WithCredentials(path string) AuthOption {
+	return func(c *authConfig) {
+		c.credentials = path
+	}
+}
+
+// WithServiceAccount uses a service account JSON key instead of OAuth
+// client credentials.  No browser is needed, which makes it suitable
+// for CI.  The Drive folders need to be shared with the service
+// account.  Defaults to $GDOC_SERVICE_ACCOUNT, or if no credentials
+// are given at all, $GOOGLE_APPLICATION_CREDENTIALS when it is a
+// service account key.
+func WithServiceAccount(path string) AuthOption {
+	return func(c *authConfig) {
+		c.serviceAccount = path
+	}
+}
+
+// WithTokenFile sets where the OAuth token is cached.  Defaults to
+// $GDOC_TOKEN or "googledrive2hugo/token-<scopes>.json" in the user
+// config directory.
+func WithTokenFile(path string) AuthOption {
+	return func(c *authConfig) {
+		c.tokenFile = path
+	}
+}
+
+// WithScopes sets the OAuth scopes to ask for.  Defaults to
+// drive.DriveReadonlyScope, which is needed to export docs.  Use
+// drive.DriveMetadataReadonlyScope if only walking.
+//
+// The cached token has the scopes it was made with, so the default
+// token file is named after the scopes.
+func WithScopes(scopes ...string) AuthOption {
+	return func(c *authConfig) {
+		c.scopes = scopes
+	}
+}
 
-// Retrieve a token, saves the token, then returns the generated client.
-func getClient(config *oauth2.Config) *http.Client {
-	// The file token.json stores the user's access and refresh tokens, and is
-	// created automatically when the authorization flow completes for the first
-	// time.
-	tokFile := "token.json"
-	tok, err := tokenFromFile(tokFile)
+// ReauthError means the cached OAuth token can no longer be
+// refreshed, because it expired or was revoked.  The user needs to
+// authorize again.
+type ReauthError struct {
+	TokenFile string
+	Err       error
+}
+
+func (e *ReauthError) Error() string {
+	return fmt.Sprintf("oauth token %q is expired or revoked, delete it and run again to re-authorize: %s", e.TokenFile, e.Err)
+}
+
+func (e *ReauthError) Unwrap() error {
+	return e.Err
+}
+
+// IsReauth returns true if err, or an error it wraps, is a ReauthError
+func IsReauth(err error) bool {
+	var rerr *ReauthError
+	return errors.As(err, &rerr)
+}
+
+// isInvalidGrant is how Google reports a refresh token that expired or
+// was revoked
+func isInvalidGrant(err error) bool {
+	var rerr *oauth2.RetrieveError
+	if !errors.As(err, &rerr) {
+		return false
+	}
+	return rerr.ErrorCode == "invalid_grant" || bytes.Contains(rerr.Body, []byte("invalid_grant"))
+}
+
+// persistTokenSource saves the token to a file every time it is
+// refreshed, so the next run does not start with an expired token
+type persistTokenSource struct {
+	mu   sync.Mutex
+	src  oauth2.TokenSource
+	path string
+	last string
+}
+
+func (p *persistTokenSource) Token() (*oauth2.Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tok, err := p.src.Token()
 	if err != nil {
-		tok = getTokenFromWeb(config)
-		saveToken(tokFile, tok)
+		if isInvalidGrant(err) {
+			return nil, &ReauthError{TokenFile: p.path, Err: err}
+		}
+		return nil, err
+	}
+	if tok.AccessToken != p.last {
+		if err := saveToken(p.path, tok); err != nil {
+			return nil, err
+		}
+		p.last = tok.AccessToken
 	}
-	return config.Client(context.Background(), tok)
+	return tok, nil
 }
 
-// Request a token from the web, then returns the retrieved token.
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Printf("Go to the following link in your browser then type the "+
-		"authorization code: \n%v\n", authURL)
+func newAuthConfig(opts []AuthOption) (*authConfig, error) {
+	c := &authConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
 
-	var authCode string
-	if _, err := fmt.Scan(&authCode); err != nil {
-		log.Fatalf("Unable to read authorization code %v", err)
+	// credentials given as options win over the environment, and
+	// $GOOGLE_APPLICATION_CREDENTIALS is the last resort, as it is
+	// often the user's own gcloud login rather than a service account
+	if c.credentials == "" && c.serviceAccount == "" {
+		c.credentials = os.Getenv(EnvCredentials)
+		c.serviceAccount = os.Getenv(EnvServiceAccount)
 	}
+	if c.credentials == "" && c.serviceAccount == "" {
+		if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); keyType(path) == "service_account" {
+			c.serviceAccount = path
+		}
+	}
+	if c.tokenFile == "" {
+		c.tokenFile = os.Getenv(EnvToken)
+	}
+	if c.credentials == "" {
+		c.credentials = "credentials.json"
+	}
+	if len(c.scopes) == 0 {
+		c.scopes = []string{drive.DriveReadonlyScope}
+	}
+	if c.tokenFile == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		c.tokenFile = filepath.Join(dir, "googledrive2hugo", tokenName(c.scopes))
+	}
+	return c, nil
+}
 
-	tok, err := config.Exchange(context.TODO(), authCode)
+// tokenName is the name of the default token cache for a set of
+// scopes, such as "token-drive.readonly.json".  A token only works for
+// the scopes it was made with, so drivewalk and gdoc-export each get
+// their own.
+func tokenName(scopes []string) string {
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		names = append(names, path.Base(scope))
+	}
+	sort.Strings(names)
+	return "token-" + strings.Join(names, "+") + ".json"
+}
+
+// keyType returns the "type" of a Google credentials JSON file, such
+// as "service_account" or "authorized_user", or "" if it can't be read
+func keyType(path string) string {
+	if path == "" {
+		return ""
+	}
+	b, err := ioutil.ReadFile(path)
 	if err != nil {
-		log.Fatalf("Unable to retrieve token from web %v", err)
+		return ""
+	}
+	var key struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(b, &key) != nil {
+		return ""
 	}
-	return tok
+	return key.Type
+}
+
+// Client returns a HTTP client authorized for Google Drive
+func Client(opts ...AuthOption) (*http.Client, error) {
+	conf, err := newAuthConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	scopes := conf.scopes
+
+	// service accounts do not need a browser or a token cache
+	if conf.serviceAccount != "" {
+		b, err := ioutil.ReadFile(conf.serviceAccount)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read service account key: %s", err)
+		}
+		if t := keyType(conf.serviceAccount); t != "service_account" {
+			return nil, fmt.Errorf("%q is not a service account key (type %q)", conf.serviceAccount, t)
+		}
+		jwt, err := google.JWTConfigFromJSON(b, scopes...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse service account key %q: %s", conf.serviceAccount, err)
+		}
+		return jwt.Client(ctx), nil
+	}
+
+	b, err := ioutil.ReadFile(conf.credentials)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client secret file: %s", err)
+	}
+	config, err := google.ConfigFromJSON(b, scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse client secret file %q: %s", conf.credentials, err)
+	}
+
+	tok, err := tokenFromFile(conf.tokenFile)
+	if err != nil {
+		tok, err = getTokenFromWeb(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		if err = saveToken(conf.tokenFile, tok); err != nil {
+			return nil, err
+		}
+	}
+	ts := &persistTokenSource{
+		src:  config.TokenSource(ctx, tok),
+		path: conf.tokenFile,
+		last: tok.AccessToken,
+	}
+
+	// refresh now, so an expired or revoked token is reported here
+	// and not as a failure in the middle of a walk
+	if _, err := ts.Token(); err != nil {
+		return nil, err
+	}
+	return oauth2.NewClient(ctx, ts), nil
+}
+
+// Setup returns a Google Drive service, authorized using the options
+func Setup(opts ...AuthOption) (*drive.Service, error) {
+	client, err := Client(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return drive.New(client)
+}
+
+// getTokenFromWeb asks the user to authorize in a browser.  Google
+// redirects back to a temporary server on the loopback interface, so
+// there is no code to copy and paste.
+func getTokenFromWeb(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer ln.Close()
+
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+
+	// copy so the redirect doesn't leak to the caller's config
+	local := *config
+	local.RedirectURL = fmt.Sprintf("http://%s/", ln.Addr())
+
+	type result struct {
+		code string
+		err  error
+	}
+	done := make(chan result, 1)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			switch {
+			case q.Get("state") != state:
+				http.Error(w, "invalid state", http.StatusBadRequest)
+				return
+			case q.Get("error") != "":
+				done <- result{err: fmt.Errorf("authorization failed: %s", q.Get("error"))}
+				fmt.Fprintln(w, "Authorization failed, you may close this window.")
+			default:
+				done <- result{code: q.Get("code")}
+				fmt.Fprintln(w, "Authorization complete, you may close this window.")
+			}
+		}),
+	}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	authURL := local.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	fmt.Fprintf(os.Stderr, "Go to the following link in your browser to authorize:\n%v\n", authURL)
+
+	var res result
+	select {
+	case res = <-done:
+	case <-time.After(authTimeout):
+		return nil, fmt.Errorf("timed out waiting for authorization")
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	tok, err := local.Exchange(ctx, res.code)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve token from web: %s", err)
+	}
+	return tok, nil
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
 // Retrieves a token from a local file.
@@ -61,31 +366,18 @@ func tokenFromFile(file string) (*oauth2.Token, error) {
 	return tok, err
 }
 
-// Saves a token to a file path.
-func saveToken(path string, token *oauth2.Token) {
-	fmt.Printf("Saving credential file to: %s\n", path)
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		log.Fatalf("Unable to cache oauth token: %v", err)
+// Saves a token to a file path.  The file is replaced atomically so
+// a crash never leaves a truncated token.
+func saveToken(path string, token *oauth2.Token) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("unable to cache oauth token: %s", err)
 	}
-	defer f.Close()
-	json.NewEncoder(f).Encode(token)
-}
-
-// setup is a temp function to read secrets and make a drive service
-func Setup() (*drive.Service, error) {
-	b, err := ioutil.ReadFile("credentials.json")
+	raw, err := json.Marshal(token)
 	if err != nil {
-		log.Fatalf("Unable to read client secret file: %v", err)
+		return err
 	}
-
-	// If modifying these scopes, delete your previously saved credentials
-	// at ~/.credentials/drive-go-quickstart.json
-	config, err := google.ConfigFromJSON(b, drive.DriveMetadataReadonlyScope, drive.DriveReadonlyScope)
-	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+	if err := WriteFileAtomic(path, raw, 0600); err != nil {
+		return fmt.Errorf("unable to cache oauth token: %s", err)
 	}
-	client := getClient(config)
-
-	return drive.New(client)
+	return nil
 }
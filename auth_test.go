@@ -0,0 +1,148 @@
+package googledrive2hugo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestAuthConfig(t *testing.T) {
+	for _, k := range []string{EnvCredentials, EnvServiceAccount, EnvToken, "GOOGLE_APPLICATION_CREDENTIALS"} {
+		defer os.Setenv(k, os.Getenv(k))
+		os.Unsetenv(k)
+	}
+
+	conf, err := newAuthConfig(nil)
+	if err != nil {
+		t.Fatalf("unable to make config: %s", err)
+	}
+	if conf.credentials != "credentials.json" || conf.serviceAccount != "" || conf.tokenFile == "" {
+		t.Errorf("unexpected defaults %+v", conf)
+	}
+
+	dir, err := ioutil.TempDir("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	account := filepath.Join(dir, "account.json")
+	adc := filepath.Join(dir, "adc.json")
+	ioutil.WriteFile(account, []byte(`{"type": "service_account"}`), 0600)
+	ioutil.WriteFile(adc, []byte(`{"type": "authorized_user"}`), 0600)
+
+	// only a service account key is used from GOOGLE_APPLICATION_CREDENTIALS
+	os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", account)
+	conf, err = newAuthConfig(nil)
+	if err != nil {
+		t.Fatalf("unable to make config: %s", err)
+	}
+	if conf.serviceAccount != account {
+		t.Errorf("GOOGLE_APPLICATION_CREDENTIALS not used %+v", conf)
+	}
+	os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", adc)
+	conf, err = newAuthConfig(nil)
+	if err != nil {
+		t.Fatalf("unable to make config: %s", err)
+	}
+	if conf.serviceAccount != "" {
+		t.Errorf("user credentials used as a service account %+v", conf)
+	}
+
+	// and not when credentials are given
+	os.Setenv("GOOGLE_APPLICATION_CREDENTIALS", account)
+	os.Setenv(EnvCredentials, "env.json")
+	conf, err = newAuthConfig(nil)
+	if err != nil {
+		t.Fatalf("unable to make config: %s", err)
+	}
+	if conf.credentials != "env.json" || conf.serviceAccount != "" {
+		t.Errorf("environment not used %+v", conf)
+	}
+
+	os.Setenv(EnvServiceAccount, "env-account.json")
+	conf, err = newAuthConfig([]AuthOption{
+		WithCredentials("flag.json"),
+		WithTokenFile("token.json"),
+	})
+	if err != nil {
+		t.Fatalf("unable to make config: %s", err)
+	}
+	if conf.credentials != "flag.json" || conf.serviceAccount != "" || conf.tokenFile != "token.json" {
+		t.Errorf("options did not override environment %+v", conf)
+	}
+}
+
+func TestTokenName(t *testing.T) {
+	walk := tokenName([]string{drive.DriveMetadataReadonlyScope})
+	export := tokenName([]string{drive.DriveReadonlyScope})
+	if walk == export {
+		t.Errorf("scopes share token file %q", walk)
+	}
+	if export != "token-drive.readonly.json" {
+		t.Errorf("got %q", export)
+	}
+}
+
+type fakeTokenSource struct {
+	tokens []*oauth2.Token
+	err    error
+}
+
+func (f *fakeTokenSource) Token() (*oauth2.Token, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	tok := f.tokens[0]
+	if len(f.tokens) > 1 {
+		f.tokens = f.tokens[1:]
+	}
+	return tok, nil
+}
+
+func TestPersistTokenSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "token.json")
+
+	first := &oauth2.Token{AccessToken: "first", RefreshToken: "refresh"}
+	refreshed := &oauth2.Token{AccessToken: "second", RefreshToken: "refresh"}
+	ts := &persistTokenSource{
+		src:  &fakeTokenSource{tokens: []*oauth2.Token{first, refreshed}},
+		path: path,
+		last: "first",
+	}
+
+	// unchanged token is not saved
+	if _, err := ts.Token(); err != nil {
+		t.Fatalf("unable to get token: %s", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("token saved when not refreshed")
+	}
+
+	// refreshed token is
+	if _, err := ts.Token(); err != nil {
+		t.Fatalf("unable to get token: %s", err)
+	}
+	saved, err := tokenFromFile(path)
+	if err != nil {
+		t.Fatalf("refreshed token not saved: %s", err)
+	}
+	if saved.AccessToken != "second" || saved.RefreshToken != "refresh" {
+		t.Errorf("wrong token saved %+v", saved)
+	}
+
+	// revoked
+	ts.src = &fakeTokenSource{err: &oauth2.RetrieveError{ErrorCode: "invalid_grant"}}
+	_, err = ts.Token()
+	if !IsReauth(err) {
+		t.Errorf("expected re-auth error, got %v", err)
+	}
+}
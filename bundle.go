@@ -0,0 +1,112 @@
+package googledrive2hugo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+var (
+	selectorImgSrc = cascadia.MustCompile("img[src]")
+
+	// names given to bundled images.  See bundleName
+	reBundleName = regexp.MustCompile(`^[0-9a-f]{16}\.[a-z]+$`)
+)
+
+// ImageFetcher returns the contents of the image found at an
+// <img src>
+type ImageFetcher func(src string) ([]byte, error)
+
+// HTTPImageFetcher downloads images using a HTTP client.  The images
+// in an exported Google Doc are on googleusercontent.com and do not
+// need any authorization, but the URLs expire.
+func HTTPImageFetcher(client *http.Client) ImageFetcher {
+	return func(src string) ([]byte, error) {
+		u, err := url.Parse(src)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("unable to fetch image %q", src)
+		}
+		resp, err := client.Get(src)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unable to fetch image %q: %s", src, resp.Status)
+		}
+		return ioutil.ReadAll(resp.Body)
+	}
+}
+
+// IsBundleImage returns true if a filename looks like one made for an
+// image by ToBundle
+func IsBundleImage(name string) bool {
+	return reBundleName.MatchString(name)
+}
+
+// bundleImages fetches every <img src>, and rewrites the src to a
+// local filename made from a hash of the content.  An image that
+// doesn't change keeps the same name.
+func bundleImages(root *html.Node, fetch ImageFetcher) (map[string][]byte, error) {
+	images := make(map[string][]byte)
+	names := make(map[string]string)
+	for _, img := range selectorImgSrc.MatchAll(root) {
+		for i, attr := range img.Attr {
+			if attr.Key != "src" {
+				continue
+			}
+			name, ok := names[attr.Val]
+			if !ok {
+				raw, err := fetch(attr.Val)
+				if err != nil {
+					return nil, err
+				}
+				name = bundleName(attr.Val, raw)
+				names[attr.Val] = name
+				images[name] = raw
+			}
+			img.Attr[i].Val = name
+		}
+	}
+	return images, nil
+}
+
+// bundleName is the first 16 hex digits of the SHA-256 of the image,
+// with an extension based on the content, or failing that, the
+// original URL.
+func bundleName(src string, raw []byte) string {
+	sum := sha256.Sum256(raw)
+	name := hex.EncodeToString(sum[:])[:16]
+
+	switch http.DetectContentType(raw) {
+	case "image/png":
+		return name + ".png"
+	case "image/jpeg":
+		return name + ".jpg"
+	case "image/gif":
+		return name + ".gif"
+	case "image/webp":
+		return name + ".webp"
+	case "image/bmp":
+		return name + ".bmp"
+	}
+	if u, err := url.Parse(src); err == nil {
+		ext := strings.ToLower(path.Ext(u.Path))
+		if ext == ".svg" {
+			return name + ext
+		}
+	}
+	return name + ".img"
+}
@@ -0,0 +1,50 @@
+package googledrive2hugo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/client9/ilog"
+)
+
+func TestToBundle(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nfake image")
+	fetched := 0
+	fetch := func(src string) ([]byte, error) {
+		fetched++
+		if src != "https://lh3.googleusercontent.com/abc" {
+			return nil, fmt.Errorf("unexpected src %q", src)
+		}
+		return png, nil
+	}
+	doc := `<html><body>
+<p><span><img src="https://lh3.googleusercontent.com/abc"></span></p>
+<p><span><img src="https://lh3.googleusercontent.com/abc"></span></p>
+</body></html>`
+
+	c := Converter{
+		Logger: &ilog.NopLogger{},
+	}
+	out, images, err := c.ToBundle([]byte(doc), map[string]interface{}{"date": "2018-10-01T00:00:00Z"}, fetch)
+	if err != nil {
+		t.Fatalf("unable to convert: %s", err)
+	}
+	if fetched != 1 {
+		t.Errorf("expected image to be fetched once, got %d", fetched)
+	}
+	if len(images) != 1 {
+		t.Fatalf("expected one image, got %d", len(images))
+	}
+	for name, raw := range images {
+		if !IsBundleImage(name) || !strings.HasSuffix(name, ".png") {
+			t.Errorf("unexpected image name %q", name)
+		}
+		if string(raw) != string(png) {
+			t.Errorf("wrong image content")
+		}
+		if strings.Count(string(out), fmt.Sprintf("src=%q", name)) != 2 {
+			t.Errorf("src not rewritten to %q in %s", name, out)
+		}
+	}
+}
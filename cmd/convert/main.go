@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/client9/googledrive2hugo"
+	"github.com/client9/ilog/stdlib/adapter"
+	"google.golang.org/api/drive/v3"
+)
+
+// metaFlags collects -meta key=value front matter overrides
+type metaFlags map[string]interface{}
+
+func (m metaFlags) String() string {
+	return fmt.Sprint(map[string]interface{}(m))
+}
+
+func (m metaFlags) Set(value string) error {
+	idx := strings.IndexByte(value, '=')
+	if idx < 1 {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	m[value[:idx]] = googledrive2hugo.PropertyValue(value[:idx], value[idx+1:])
+	return nil
+}
+
+var (
+	flagConfig *string
+	flagOut    *string
+	flagFormat *string
+	flagMeta   = metaFlags{}
+)
+
+func init() {
+	flagConfig = flag.String("config", "", "config file")
+	flagOut = flag.String("o", "", "output file, default stdout")
+	flagFormat = flag.String("format", "html", "output format: html or markdown")
+	flag.Var(flagMeta, "meta", "front matter key=value, may be repeated")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [doc.html | doc.zip]\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Converts a Google Doc downloaded as \"Web page (.html, zipped)\", or its HTML, to Hugo content.  Reads stdin if no file is given.\n\n")
+		flag.PrintDefaults()
+	}
+}
+
+// readDoc reads the exported doc and its file info.  A zipped export
+// also returns its images.
+func readDoc(path string) ([]byte, map[string][]byte, *drive.File, error) {
+	if path == "" || path == "-" {
+		raw, err := ioutil.ReadAll(os.Stdin)
+		now := time.Now().UTC().Format(time.RFC3339)
+		return raw, nil, &drive.File{CreatedTime: now, ModifiedTime: now}, err
+	}
+	src := googledrive2hugo.NewLocalSource(filepath.Dir(path))
+	info, err := src.Get(filepath.Base(path))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if filepath.Ext(path) == ".zip" {
+		raw, assets, err := googledrive2hugo.ExportZip(src, info)
+		return raw, assets, info, err
+	}
+	raw, err := googledrive2hugo.ExportHTML(src, info)
+	return raw, nil, info, err
+}
+
+func main() {
+	stdlog := log.New(os.Stderr, "", 0)
+	logger := adapter.New(stdlog)
+
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *flagFormat != "html" && *flagFormat != "markdown" {
+		log.Fatalf("unknown -format %q", *flagFormat)
+	}
+
+	convert := googledrive2hugo.Converter{
+		Logger: logger,
+	}
+	if *flagConfig != "" {
+		confbytes, err := ioutil.ReadFile(*flagConfig)
+		if err != nil {
+			log.Fatalf("unable to read %q: %s", *flagConfig, err)
+		}
+		config, err := googledrive2hugo.ParseConfig(string(confbytes))
+		if err != nil {
+			log.Fatalf("unable to parse %q: %s", *flagConfig, err)
+		}
+		convert.Filters = config.Filters
+		convert.FrontMatter = config.FrontMatter
+	}
+
+	raw, assets, info, err := readDoc(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("unable to read doc: %s", err)
+	}
+	// without an output file there is nowhere to put the images, and
+	// the <img src> would point at nothing
+	if len(assets) > 0 && *flagOut == "" {
+		log.Fatalf("%s has %d images, use -o to write them next to the output", flag.Arg(0), len(assets))
+	}
+
+	// overrides win over the front matter in the doc itself
+	meta := googledrive2hugo.FileInfoToMeta(info)
+	convert.Meta = flagMeta
+
+	// images of a zipped doc go next to the output file
+	var out []byte
+	var images map[string][]byte
+	markdown := *flagFormat == "markdown"
+	bundle := len(assets) > 0 && *flagOut != ""
+	fetch := googledrive2hugo.AssetFetcher(assets, googledrive2hugo.HTTPImageFetcher(&http.Client{Timeout: time.Minute}))
+	switch {
+	case bundle && markdown:
+		out, images, err = convert.ToMarkdownBundle(raw, meta, fetch)
+	case bundle:
+		out, images, err = convert.ToBundle(raw, meta, fetch)
+	case markdown:
+		out, err = convert.ToMarkdown(raw, meta)
+	default:
+		out, err = convert.ToHTML(raw, meta)
+	}
+	if err != nil {
+		var ferr *googledrive2hugo.FrontMatterError
+		if errors.As(err, &ferr) {
+			ferr.Doc = flag.Arg(0)
+			if ferr.Doc == "" {
+				ferr.Doc = "stdin"
+			}
+		}
+		log.Fatalf("unable to convert: %s", err)
+	}
+
+	if *flagOut == "" {
+		os.Stdout.Write(out)
+		return
+	}
+	for name, img := range images {
+		if err = ioutil.WriteFile(filepath.Join(filepath.Dir(*flagOut), name), img, 0644); err != nil {
+			log.Fatalf("unable to write image: %s", err)
+		}
+	}
+	if err = ioutil.WriteFile(*flagOut, out, 0644); err != nil {
+		log.Fatalf("unable to write %q: %s", *flagOut, err)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/client9/googledrive2hugo"
+	"github.com/client9/ilog/stdlib/adapter"
+)
+
+func TestReadDoc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "convert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	doc := `<html><body><p><img src="images/image1.png"></p></body></html>`
+	if err := ioutil.WriteFile(filepath.Join(dir, "post.html"), []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "post.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"Post.html":         doc,
+		"images/image1.png": "png",
+	} {
+		zf, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zf.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	raw, assets, info, err := readDoc(filepath.Join(dir, "post.html"))
+	if err != nil {
+		t.Fatalf("unable to read html: %s", err)
+	}
+	if string(raw) != doc || len(assets) != 0 || info.Name != "post" {
+		t.Errorf("html: got %q %v %q", raw, assets, info.Name)
+	}
+
+	raw, assets, _, err = readDoc(filepath.Join(dir, "post.zip"))
+	if err != nil {
+		t.Fatalf("unable to read zip: %s", err)
+	}
+	if string(raw) != doc || string(assets["images/image1.png"]) != "png" {
+		t.Errorf("zip: got %q %v", raw, assets)
+	}
+
+	if _, _, _, err = readDoc(filepath.Join(dir, "missing.html")); err == nil {
+		t.Errorf("expected error for a missing doc")
+	}
+}
+
+func TestMetaFlags(t *testing.T) {
+	m := metaFlags{}
+	for _, v := range []string{"title=Override", "draft=true", "weight=3", "aliases=[/old, /older]", "x=a=b", "slug=007", "year=2024"} {
+		if err := m.Set(v); err != nil {
+			t.Fatalf("%q: %s", v, err)
+		}
+	}
+	want := metaFlags{
+		"title":   "Override",
+		"draft":   true,
+		"weight":  3,
+		"aliases": []string{"/old", "/older"},
+		"x":       "a=b",
+		"slug":    "007",
+		"year":    "2024",
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("want %v got %v", want, m)
+	}
+	for _, v := range []string{"title", "=x"} {
+		if err := (metaFlags{}).Set(v); err == nil {
+			t.Errorf("%q: expected error", v)
+		}
+	}
+
+	// overrides win over the file meta
+	convert := googledrive2hugo.Converter{
+		Logger: adapter.New(log.New(ioutil.Discard, "", 0)),
+		Meta:   metaFlags{"title": "Override"},
+	}
+	meta := map[string]interface{}{"title": "Doc", "date": "2020-01-02T03:04:05Z"}
+	out, err := convert.ToHTML([]byte("<html><body><p>hi</p></body></html>"), meta)
+	if err != nil {
+		t.Fatalf("unable to convert: %s", err)
+	}
+	if !strings.Contains(string(out), "title: Override\n") {
+		t.Errorf("override not used in\n%s", out)
+	}
+}
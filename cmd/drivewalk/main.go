@@ -9,7 +9,7 @@ import (
 )
 
 // sample WalkFn
-func printer(srv *drive.Service, path string, info *drive.File, err error) error {
+func printer(src googledrive2hugo.DriveSource, path string, info *drive.File, err error) error {
 	if err != nil {
 		log.Printf("Error on %q, %s", path, err)
 		return nil
@@ -20,15 +20,51 @@ func printer(srv *drive.Service, path string, info *drive.File, err error) error
 
 func main() {
 	rootFlag := flag.String("root", "My Drive", "root to walk")
+	idFlag := flag.String("root-id", "", "ID of root folder to walk instead of -root")
+	queryFlag := flag.String("query", "", "drive search query to walk instead of -root")
+	driveFlag := flag.String("drive", "", "name or ID of shared drive to walk")
+	credsFlag := flag.String("credentials", "", "OAuth client credentials file")
+	accountFlag := flag.String("service-account", "", "service account key file to use instead of OAuth")
 	flag.Parse()
 
-	srv, err := googledrive2hugo.Setup()
+	// only walking, no need to read the docs themselves.  Shared
+	// drives can only be looked up with read access.
+	scope := drive.DriveMetadataReadonlyScope
+	if *driveFlag != "" {
+		scope = drive.DriveReadonlyScope
+	}
+	opts := []googledrive2hugo.AuthOption{
+		googledrive2hugo.WithScopes(scope),
+	}
+	if *credsFlag != "" {
+		opts = append(opts, googledrive2hugo.WithCredentials(*credsFlag))
+	}
+	if *accountFlag != "" {
+		opts = append(opts, googledrive2hugo.WithServiceAccount(*accountFlag))
+	}
+	srv, err := googledrive2hugo.Setup(opts...)
 	if err != nil {
 		log.Fatalf("unable to auth: %s", err)
 	}
 
-	err = googledrive2hugo.Walk(srv, *rootFlag, printer)
+	service := googledrive2hugo.NewDriveService(srv)
+	if *driveFlag != "" {
+		if err = service.UseSharedDrive(*driveFlag); err != nil {
+			log.Fatalf("unable to use shared drive: %s", err)
+		}
+	}
+
+	var stats googledrive2hugo.WalkStats
+	switch {
+	case *queryFlag != "":
+		stats, err = googledrive2hugo.WalkQuery(service, *queryFlag, printer)
+	case *idFlag != "":
+		stats, err = googledrive2hugo.WalkID(service, *idFlag, printer)
+	default:
+		stats, err = googledrive2hugo.Walk(service, *rootFlag, printer)
+	}
 	if err != nil {
 		log.Fatalf("walk failed: %s", err)
 	}
+	log.Printf("DONE folders=%d, children=%d", len(stats), stats.Total())
 }
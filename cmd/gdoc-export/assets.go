@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/client9/googledrive2hugo"
+	"github.com/client9/ilog"
+	"google.golang.org/api/drive/v3"
+)
+
+// isStaticAsset is true for files exported to the asset directory
+func isStaticAsset(f *drive.File) bool {
+	return googledrive2hugo.IsDrawing(f) || googledrive2hugo.IsPresentation(f)
+}
+
+// assetFormat is the format, and file extension, to export f as
+func assetFormat(f *drive.File) string {
+	if googledrive2hugo.IsDrawing(f) {
+		return *flagDrawing
+	}
+	return *flagSlides
+}
+
+// assetDir is where drawings and slides are written: next to the docs
+// as bundle resources with -bundle-assets, or the static directory
+func assetDir() string {
+	if *flagBundleAssets {
+		return *flagOut
+	}
+	return *flagStatic
+}
+
+// assetPath is the output path of a drawing or slides.  A PNG of every
+// slide is written to a directory.
+func assetPath(path string, f *drive.File) string {
+	format := assetFormat(f)
+	if format == googledrive2hugo.SlidesPNGs {
+		return filepath.Join(assetDir(), path)
+	}
+	return filepath.Join(assetDir(), path) + "." + format
+}
+
+// exportAsset writes a drawing or slides to the asset directory
+func exportAsset(src googledrive2hugo.DriveSource, j *job, logger ilog.Logger) error {
+	logger.Debug("reading asset", "path", j.origpath)
+	if assetFormat(j.info) == googledrive2hugo.SlidesPNGs {
+		return exportSlides(src, j, logger)
+	}
+	raw, err := googledrive2hugo.ExportAsset(src, j.info, assetFormat(j.info))
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(j.outpath), 0755); err != nil {
+		return err
+	}
+	logger.Debug("writing asset", "path", j.outpath)
+	return googledrive2hugo.WriteFileAtomic(j.outpath, raw, 0644)
+}
+
+// exportSlides writes each slide of a deck as a PNG in the directory
+// j.outpath.  Images of slides that no longer exist are removed.
+func exportSlides(src googledrive2hugo.DriveSource, j *job, logger ilog.Logger) error {
+	images, err := googledrive2hugo.ExportSlides(src, j.info)
+	if err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		return fmt.Errorf("%s: no slides", j.origpath)
+	}
+	if err = os.MkdirAll(j.outpath, 0755); err != nil {
+		return err
+	}
+	written := make(map[string]bool)
+	for i, raw := range images {
+		path := filepath.Join(j.outpath, googledrive2hugo.SlideImage(i+1))
+		logger.Debug("writing slide", "path", path)
+		if err = googledrive2hugo.WriteFileAtomic(path, raw, 0644); err != nil {
+			return err
+		}
+		written[path] = true
+	}
+	files, err := slideFiles(j.outpath)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if !written[path] {
+			if err = os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// slideFiles lists the slide images written for a deck
+func slideFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []string
+	for _, fi := range entries {
+		if !fi.IsDir() && googledrive2hugo.IsSlideImage(fi.Name()) {
+			out = append(out, filepath.Join(dir, fi.Name()))
+		}
+	}
+	return out, nil
+}
+
+// assetURLs maps the Drive ID of each drawing and slides in the walk to
+// its URL on the site.  Links to slides exported as PNGs go to the
+// first slide.
+func assetURLs(jobs []*job) map[string]string {
+	urls := make(map[string]string)
+	for _, j := range jobs {
+		if !isStaticAsset(j.info) {
+			continue
+		}
+		rel, err := filepath.Rel(assetDir(), j.outpath)
+		if err != nil {
+			continue
+		}
+		if assetFormat(j.info) == googledrive2hugo.SlidesPNGs {
+			rel = filepath.Join(rel, googledrive2hugo.SlideImage(1))
+		}
+		id := googledrive2hugo.TargetID(j.info)
+		if _, ok := urls[id]; !ok {
+			urls[id] = "/" + filepath.ToSlash(rel)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/client9/googledrive2hugo"
+)
+
+// index of a leaf bundle, "index.md" with -format markdown
+var bundleIndex = "index.html"
+
+// isBundle is true for the index.html of a leaf bundle, and with
+// -bundle, the _index.html of a branch bundle
+func isBundle(outpath string) bool {
+	base := filepath.Base(outpath)
+	return base == bundleIndex || (*flagBundle && base == sectionIndex)
+}
+
+// writeImages writes the images of a leaf bundle next to its
+// index.html.  Image names are content hashes, so existing images are
+// not rewritten.  Images no longer used by the doc are removed.
+func writeImages(outpath string, images map[string][]byte) error {
+	dir := filepath.Dir(outpath)
+	for name, raw := range images {
+		path := filepath.Join(dir, name)
+		if fileExists(path) {
+			continue
+		}
+		if err := googledrive2hugo.WriteFileAtomic(path, raw, 0644); err != nil {
+			return err
+		}
+	}
+	names, err := bundleImages(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, ok := images[name]; !ok {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bundleImages lists the images written to a bundle directory
+func bundleImages(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, fi := range entries {
+		if !fi.IsDir() && googledrive2hugo.IsBundleImage(fi.Name()) {
+			names = append(names, fi.Name())
+		}
+	}
+	return names, nil
+}
+
+// bundleFiles is all the files of a bundle, starting with index.html
+func bundleFiles(outpath string) ([]string, error) {
+	out := []string{outpath}
+	if !isBundle(outpath) {
+		return out, nil
+	}
+	names, err := bundleImages(filepath.Dir(outpath))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		out = append(out, filepath.Join(filepath.Dir(outpath), name))
+	}
+	return out, nil
+}
@@ -1,16 +1,23 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/client9/googledrive2hugo"
 	"github.com/client9/ilog"
 	"github.com/client9/ilog/stdlib/adapter"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/slides/v1"
 
 	// for saving intermediate HTML.  The google generated html is
 	// compressed
@@ -23,122 +30,699 @@ var (
 )
 
 var (
-	flagConfig   *string
-	flagRoot     *string
-	flagOut      *string
-	flagSanitize *bool
-	flagSaveTmp  *string
+	flagConfig       *string
+	flagRoot         *string
+	flagOut          *string
+	flagSanitize     *bool
+	flagSaveTmp      *string
+	flagLocal        *string
+	flagState        *string
+	flagForce        *bool
+	flagDryRun       *bool
+	flagArchive      *string
+	flagJobs         *int
+	flagRate         *float64
+	flagDrive        *string
+	flagBundle       *bool
+	flagZip          *bool
+	flagCreds        *string
+	flagAccount      *string
+	flagToken        *string
+	flagRootID       *string
+	flagQuery        *string
+	flagData         *string
+	flagDataFmt      *string
+	flagStatic       *string
+	flagBundleAssets *bool
+	flagDrawing      *string
+	flagSlides       *string
+	flagRef          *bool
+	flagPublish      *string
+	flagHeld         *string
+	flagRevision     *string
+	flagWatch        *bool
+	flagInterval     *time.Duration
+	flagHook         *string
+	flagFormat       *string
+	flagFront        *string
 )
 
 func init() {
 	flagConfig = flag.String("config", "godoc-export.sh", "config file")
 	flagRoot = flag.String("root", "", "root dir in google drive to use")
+	flagRootID = flag.String("root-id", "", "ID of root folder to use instead of -root")
+	flagQuery = flag.String("query", "", "drive search query for docs to use instead of -root")
 	flagOut = flag.String("out", ".", "output directory")
 	flagSaveTmp = flag.String("tmp", "", "directory to save intermediate files")
 	flagSanitize = flag.Bool("sanitize-filename", true, "sanitize gdoc filename")
-	flag.Parse()
+	flagLocal = flag.String("local", "", "read exported HTML from this directory instead of google drive")
+	flagState = flag.String("state", ".gdoc-export.json", "file to record exported docs, empty to disable")
+	flagForce = flag.Bool("force", false, "export all docs, even if unchanged")
+	flagDryRun = flag.Bool("dry-run", false, "print what would be done, but don't do it")
+	flagArchive = flag.String("archive", "", "move output of removed docs here instead of deleting")
+	flagJobs = flag.Int("jobs", 4, "number of docs to export at once")
+	flagRate = flag.Float64("rate", 10, "maximum google drive requests per second")
+	flagDrive = flag.String("drive", "", "name or ID of shared drive to use instead of My Drive")
+	flagBundle = flag.Bool("bundle", false, "write each doc as a leaf bundle with its images")
+	flagZip = flag.Bool("zip", false, "export docs as zipped HTML, with images.  Requires -bundle")
+	flagCreds = flag.String("credentials", "", "OAuth client credentials file (default $GDOC_CREDENTIALS or credentials.json)")
+	flagAccount = flag.String("service-account", "", "service account key file to use instead of OAuth")
+	flagToken = flag.String("token", "", "OAuth token cache file (default in user config dir)")
+	flagData = flag.String("data", "", "hugo data directory for google sheets, empty to skip sheets")
+	flagDataFmt = flag.String("data-format", "json", "format of data files: json, yaml or csv")
+	flagStatic = flag.String("static", "", "hugo static directory for google drawings and slides, empty to skip them")
+	flagBundleAssets = flag.Bool("bundle-assets", false, "write google drawings and slides next to the docs in the output directory, instead of -static")
+	flagDrawing = flag.String("drawing-format", "svg", "format of drawings: svg, png or pdf")
+	flagSlides = flag.String("slides-format", "pdf", "format of slides: pdf, png for the first slide, or pngs for every slide")
+	flagRef = flag.Bool("ref", false, "link between docs with the hugo ref shortcode instead of URLs")
+	flagPublish = flag.String("publish", "all", "docs to publish: all, starred, or property:<key> for docs with that drive property set to true")
+	flagHeld = flag.String("unpublished", "skip", "what to do with docs not ready to publish: skip, or draft to export with draft: true")
+	flagRevision = flag.String("revision", "head", "revision of docs to export: head, or kept for the latest revision kept forever or published.  Revisions pinned in the state file are always used")
+	flagWatch = flag.Bool("watch", false, "keep running, exporting docs as they change")
+	flagInterval = flag.Duration("interval", 30*time.Second, "how often to check for changes in watch mode")
+	flagHook = flag.String("hook", "", "shell command to run after docs were exported in watch mode, such as \"hugo\"")
+	flagFormat = flag.String("format", "html", "output format of docs: html or markdown")
+	flagFront = flag.String("front-matter", "", "front matter format: yaml, toml or json (default from the config file, or yaml).  Use -force to rewrite docs already exported")
+}
+
+// front matter from drive file fields, set by the config file
+var metaMap = googledrive2hugo.DefaultMetaMap
+
+// job is a google doc, sheet, drawing or slides found during the
+// walk, to be exported
+type job struct {
+	origpath string // path in google drive
+	outpath  string // output file, or directory for a sheet
+	info     *drive.File
+	section  *drive.File // folder, if this is the section page of it
+	draft    bool        // not ready to publish, export as a draft
+	revision string      // revision to export, if not the head
+}
+
+// held is a doc that was not ready to publish
+type held struct {
+	path   string
+	reason string
 }
 
 // sample WalkFn
-func walker(c googledrive2hugo.Converter, logger ilog.Logger) googledrive2hugo.WalkFunc {
-	return func(srv *drive.Service, path string, info *drive.File, err error) error {
+//
+// Every file and folder seen is added to watched, by Drive ID, so
+// watch mode knows which changes matter.  Errors, such as a folder
+// that could not be listed, are counted in walkErrs: the walk goes on,
+// but it is incomplete.
+func walker(jobs *[]*job, heldBack *[]held, watched map[string]bool, walkErrs *int, publish googledrive2hugo.PublishPolicy, logger ilog.Logger) googledrive2hugo.WalkFunc {
+	ids := make(map[string]bool)
+	return func(src googledrive2hugo.DriveSource, path string, info *drive.File, err error) error {
 		origpath := path
 		if err != nil {
-			logger.Debug("walk error", "path", path, "err", err)
+			logger.Error("walk error", "path", path, "err", err)
+			*walkErrs++
 			return nil
 		}
+		watched[googledrive2hugo.TargetID(info)] = true
 
 		if *flagSanitize {
 			path = googledrive2hugo.URLize(path)
 		}
 
-		if googledrive2hugo.IsDir(info) {
-			outpath := filepath.Dir(filepath.Join(*flagOut, path))
-			if outpath == "." || outpath == ".." {
-				return nil
-			}
-			// TODO ADD DRY RUN
-			err = os.MkdirAll(outpath, 0755)
-			if err != nil {
-				return err
-			}
+		// if not a google doc or something else exported, skip (or if
+		// directory allow Walk to descend)
+		isDir := googledrive2hugo.IsDir(info)
+		isSheet := googledrive2hugo.IsSpreadsheet(info) && *flagData != ""
+		isAsset := isStaticAsset(info) && assetDir() != ""
+		if !googledrive2hugo.IsGoogleDoc(info) && !isDir && !isSheet && !isAsset {
+			logger.Debug("skipping non google doc", "name", path)
 			return nil
 		}
 
-		// if not a google doc, skip (or if directory allow Walk to descend)
-		if !googledrive2hugo.IsGoogleDoc(info) {
-			logger.Debug("skipping non google doc", "name", path)
+		// every folder but the root is a section
+		if isDir && (path == "" || *flagOut == "") {
 			return nil
 		}
-		logger.Debug("reading", "path", origpath)
-		rawhtml, err := googledrive2hugo.ExportHTML(srv, info)
+
+		// the same doc can be reached through a shortcut to a folder.
+		// track each copy separately, by path.
+		if ids[info.Id] {
+			dup := *info
+			dup.Id = info.Id + ":" + path
+			dup.ShortcutDetails = &drive.FileShortcutDetails{
+				TargetId:       googledrive2hugo.TargetID(info),
+				TargetMimeType: info.MimeType,
+			}
+			info = &dup
+		}
+		ids[info.Id] = true
+
+		draft := false
+		if googledrive2hugo.IsGoogleDoc(info) {
+			if ok, reason := publish(info); !ok {
+				*heldBack = append(*heldBack, held{path: origpath, reason: reason})
+				if *flagHeld == "skip" {
+					return nil
+				}
+				draft = true
+			}
+		}
+
+		outpath := filepath.Join(*flagOut, path) + contentExt()
+		switch {
+		case isDir:
+			outpath = filepath.Join(*flagOut, path, sectionIndex)
+		case googledrive2hugo.IsSectionIndex(info):
+			outpath = filepath.Join(*flagOut, filepath.Dir(path), sectionIndex)
+		case isSheet:
+			outpath = filepath.Join(*flagData, path)
+		case isAsset:
+			outpath = assetPath(path, info)
+		case *flagBundle:
+			outpath = filepath.Join(*flagOut, path, bundleIndex)
+		}
+		*jobs = append(*jobs, &job{
+			origpath: origpath,
+			outpath:  outpath,
+			info:     info,
+			draft:    draft,
+		})
+		return nil
+	}
+}
+
+// export reads, converts and writes a single doc.  It is called
+// concurrently from the worker pool.
+func export(src googledrive2hugo.DriveSource, c googledrive2hugo.Converter, fetch googledrive2hugo.ImageFetcher, j *job, logger ilog.Logger) error {
+	logger.Debug("reading", "path", j.origpath, "revision", j.revision)
+	var rawhtml []byte
+	var err error
+	if j.revision != "" {
+		if src, err = googledrive2hugo.AtRevision(src, j.info, j.revision); err != nil {
+			return err
+		}
+	}
+	if *flagZip {
+		var assets map[string][]byte
+		rawhtml, assets, err = googledrive2hugo.ExportZip(src, j.info)
+		fetch = googledrive2hugo.AssetFetcher(assets, fetch)
+	} else {
+		rawhtml, err = googledrive2hugo.ExportHTML(src, j.info)
+	}
+	if err != nil {
+		return err
+	}
+
+	// save raw HTML output if requested
+	if *flagSaveTmp != "" {
+		path, err := filepath.Rel(*flagOut, j.outpath)
 		if err != nil {
 			return err
 		}
+		htmlpath := filepath.Join(*flagSaveTmp, path)
+		htmldir := filepath.Dir(htmlpath)
+		if htmldir != "." {
+			if err = os.MkdirAll(htmldir, 0755); err != nil {
+				return err
+			}
+		}
+		rawhtml := htmlfmt.FormatBytes(rawhtml, "", "  ")
+		if err = ioutil.WriteFile(htmlpath, rawhtml, 0644); err != nil {
+			return err
+		}
+	}
+	fileMeta := metaMap.Meta(j.info)
+	if j.section != nil {
+		fileMeta = googledrive2hugo.MetaMerge(sectionIndexMeta(j.section), fileMeta)
+	}
+	// not ready to publish, so a draft whatever the doc says
+	if j.draft {
+		meta := map[string]interface{}{}
+		for k, v := range c.Meta {
+			meta[k] = v
+		}
+		meta["draft"] = true
+		c.Meta = meta
+	}
+	var out []byte
+	var images map[string][]byte
+	markdown := *flagFormat == "markdown"
+	switch {
+	case isBundle(j.outpath) && markdown:
+		out, images, err = c.ToMarkdownBundle(rawhtml, fileMeta, fetch)
+	case isBundle(j.outpath):
+		out, images, err = c.ToBundle(rawhtml, fileMeta, fetch)
+	case markdown:
+		out, err = c.ToMarkdown(rawhtml, fileMeta)
+	default:
+		out, err = c.ToHTML(rawhtml, fileMeta)
+	}
+	if err != nil {
+		var ferr *googledrive2hugo.FrontMatterError
+		if errors.As(err, &ferr) {
+			ferr.Doc = j.info.Name
+		}
+		return err
+	}
+	if *flagOut == "" {
+		return nil
+	}
+	outdir := filepath.Dir(j.outpath)
+	if outdir != "." {
+		if err = os.MkdirAll(outdir, 0755); err != nil {
+			return err
+		}
+	}
+	if isBundle(j.outpath) {
+		logger.Debug("writing images", "path", outdir, "count", len(images))
+		if err = writeImages(j.outpath, images); err != nil {
+			return err
+		}
+	}
+	logger.Debug("writing html", "path", j.outpath)
+	return googledrive2hugo.WriteFileAtomic(j.outpath, out, 0644)
+}
 
-		// save raw HTML output if requested
-		if *flagSaveTmp != "" {
-			htmlpath := filepath.Join(*flagSaveTmp, path) + ".html"
-			htmldir := filepath.Dir(htmlpath)
-			if htmldir != "." {
-				if err = os.MkdirAll(htmldir, 0755); err != nil {
+// apply carries out a planned rename or removal of a previous output.
+// Removed files are moved to the archive directory if one is set.
+// A leaf bundle is moved or removed along with its images, and a
+// sheet along with its data files.
+func apply(a googledrive2hugo.Action) error {
+	files, err := outputFiles(a.From)
+	if err != nil {
+		return err
+	}
+	root, dir := outputRoot(a.From), filepath.Dir(a.From)
+	sheet := isDataDir(a.From)
+	if sheet {
+		dir = a.From
+	}
+	for _, from := range files {
+		switch a.Op {
+		case googledrive2hugo.ActionRename:
+			to := a.To
+			switch {
+			case sheet:
+				to = filepath.Join(a.To, filepath.Base(from))
+			case from != a.From:
+				to = filepath.Join(filepath.Dir(a.To), filepath.Base(from))
+			}
+			if err := moveFile(from, to); err != nil {
+				return err
+			}
+		case googledrive2hugo.ActionRemove:
+			if *flagArchive == "" {
+				if err := os.Remove(from); err != nil && !os.IsNotExist(err) {
 					return err
 				}
+				continue
 			}
-			rawhtml := htmlfmt.FormatBytes(rawhtml, "", "  ")
-			if err = ioutil.WriteFile(htmlpath, rawhtml, 0644); err != nil {
+			path, err := filepath.Rel(root, from)
+			if err != nil {
+				return err
+			}
+			if err := moveFile(from, filepath.Join(*flagArchive, path)); err != nil {
 				return err
 			}
 		}
-		fileMeta := googledrive2hugo.FileInfoToMeta(info)
-		out, err := c.ToHTML(rawhtml, fileMeta)
+	}
+	removeEmptyDirs(dir, root)
+	return nil
+}
+
+// outputFiles is all the files written for a doc, sheet or slides
+func outputFiles(outpath string) ([]string, error) {
+	if isDataDir(outpath) {
+		data, err := dataFiles(outpath)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if *flagOut == "" {
-			return nil
+		images, err := slideFiles(outpath)
+		return append(data, images...), err
+	}
+	return bundleFiles(outpath)
+}
+
+// outputRoot is the directory an output was written under: the data,
+// static or output directory
+func outputRoot(outpath string) string {
+	for _, dir := range []string{*flagData, *flagStatic} {
+		if dir == "" {
+			continue
 		}
-		outpath := filepath.Join(*flagOut, path) + ".html"
-		outdir := filepath.Dir(outpath)
-		if outdir != "." {
-			if err = os.MkdirAll(outdir, 0755); err != nil {
-				return err
+		rel, err := filepath.Rel(dir, outpath)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return dir
+		}
+	}
+	return *flagOut
+}
+
+// moveFile renames a file, making any needed directories.  A missing
+// file is not an error.
+func moveFile(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents, up to the root
+// directory, if they are empty
+func removeEmptyDirs(dir, root string) {
+	root = filepath.Clean(root)
+	for dir != root && dir != "." && dir != string(filepath.Separator) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// contentExt is the extension of content files
+func contentExt() string {
+	if *flagFormat == "markdown" {
+		return ".md"
+	}
+	return ".html"
+}
+
+// docPaths maps the Drive ID of each doc in the walk to its content
+// file, relative to the output directory
+func docPaths(jobs []*job) map[string]string {
+	paths := make(map[string]string)
+	for _, j := range jobs {
+		if !googledrive2hugo.IsGoogleDoc(j.info) {
+			continue
+		}
+		rel, err := filepath.Rel(*flagOut, j.outpath)
+		if err != nil {
+			continue
+		}
+		id := googledrive2hugo.TargetID(j.info)
+		if _, ok := paths[id]; !ok {
+			paths[id] = filepath.ToSlash(rel)
+		}
+	}
+	return paths
+}
+
+func authOptions() []googledrive2hugo.AuthOption {
+	opts := []googledrive2hugo.AuthOption{
+		googledrive2hugo.WithScopes(drive.DriveReadonlyScope),
+	}
+	if *flagCreds != "" {
+		opts = append(opts, googledrive2hugo.WithCredentials(*flagCreds))
+	}
+	if *flagAccount != "" {
+		opts = append(opts, googledrive2hugo.WithServiceAccount(*flagAccount))
+	}
+	if *flagToken != "" {
+		opts = append(opts, googledrive2hugo.WithTokenFile(*flagToken))
+	}
+	return opts
+}
+
+// unchanged is true if the doc was exported before as it is now,
+// including whether it was a draft
+func unchanged(state *googledrive2hugo.State, j *job) bool {
+	if !state.Unchanged(j.info, j.outpath) {
+		return false
+	}
+	return state.Files[j.info.Id].Draft == j.draft
+}
+
+func sortedKeys(stats googledrive2hugo.WalkStats) []string {
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// syncDrive walks the drive and exports what changed since the last run.
+// It returns the IDs of every file and folder seen in the walk, and
+// the number of outputs written, moved or removed.
+func syncDrive(src googledrive2hugo.DriveSource, convert googledrive2hugo.Converter, state *googledrive2hugo.State, publish googledrive2hugo.PublishPolicy, logger ilog.Logger) (map[string]bool, int, error) {
+	var jobs []*job
+	var heldBack []held
+	watched := make(map[string]bool)
+	walkErrs := 0
+	fn := walker(&jobs, &heldBack, watched, &walkErrs, publish, logger)
+	var stats googledrive2hugo.WalkStats
+	var err error
+	switch {
+	case *flagQuery != "":
+		stats, err = googledrive2hugo.WalkQuery(src, *flagQuery, fn)
+	case *flagRootID != "":
+		stats, err = googledrive2hugo.WalkID(src, *flagRootID, fn)
+	default:
+		stats, err = googledrive2hugo.Walk(src, *flagRoot, fn)
+	}
+	for _, dir := range sortedKeys(stats) {
+		logger.Debug("listed folder", "path", dir, "children", stats[dir])
+	}
+	logger.Info("walk done", "folders", len(stats), "children", stats.Total(), "docs", len(jobs))
+	if err != nil {
+		return nil, 0, fmt.Errorf("walk failed: %s", err)
+	}
+	jobs = linkSections(jobs)
+	if jobs, err = pickRevisions(src, state, jobs, &heldBack); err != nil {
+		return nil, 0, fmt.Errorf("unable to get revisions: %s", err)
+	}
+
+	// links from docs to exported drawings and slides, and to each other
+	convert.Filters = append([]googledrive2hugo.Runner{}, convert.Filters...)
+	if urls := assetURLs(jobs); len(urls) > 0 {
+		convert.Filters = append(convert.Filters, googledrive2hugo.NewDriveLinks(urls))
+	}
+	convert.Filters = append(convert.Filters, googledrive2hugo.NewDocLinks(docPaths(jobs), *flagRef))
+
+	// previous output for docs that were removed, renamed or moved.
+	// Docs missing from an incomplete walk may still be there, so
+	// nothing is removed or moved until a walk succeeds.
+	var actions []googledrive2hugo.Action
+	if *flagOut != "" && walkErrs > 0 {
+		logger.Error("walk incomplete, not removing or moving old output", "errors", walkErrs)
+	} else if *flagOut != "" {
+		seen := make(map[string]string)
+		for _, j := range jobs {
+			seen[j.info.Id] = j.outpath
+		}
+		actions = state.Plan(seen)
+	}
+
+	// a doc now written in another format is exported again, not
+	// renamed
+	for i, a := range actions {
+		if a.Op == googledrive2hugo.ActionRename && filepath.Ext(a.From) != filepath.Ext(a.To) {
+			actions[i].Op = googledrive2hugo.ActionRemove
+			actions[i].To = ""
+		}
+	}
+
+	if *flagDryRun {
+		renamed := make(map[string]bool)
+		for _, a := range actions {
+			fmt.Println(a)
+			state.Done(a)
+			renamed[a.To] = true
+		}
+		for _, j := range jobs {
+			if *flagForce || !unchanged(state, j) || !(fileExists(j.outpath) || renamed[j.outpath]) {
+				fmt.Printf("export %s -> %s\n", j.origpath, j.outpath)
 			}
 		}
-		logger.Debug("writing html", "path", outpath)
-		if err = ioutil.WriteFile(outpath, out, 0644); err != nil {
-			return err
+		for _, h := range heldBack {
+			fmt.Printf("hold %s (%s)\n", h.path, h.reason)
 		}
-		return nil
+		return watched, 0, nil
 	}
+
+	written := 0
+	for _, a := range actions {
+		logger.Debug("applying", "action", a.String())
+		if err = apply(a); err != nil {
+			break
+		}
+		state.Done(a)
+		written++
+	}
+
+	if err == nil {
+		var todo []*job
+		for _, j := range jobs {
+			if !*flagForce && unchanged(state, j) && fileExists(j.outpath) {
+				logger.Debug("skipping unchanged", "path", j.origpath)
+				continue
+			}
+			todo = append(todo, j)
+		}
+		fetch := googledrive2hugo.HTTPImageFetcher(&http.Client{Timeout: time.Minute})
+		errs := runJobs(*flagJobs, todo, func(j *job) error {
+			switch {
+			case googledrive2hugo.IsDir(j.info):
+				return exportSection(j, convert.FrontMatter, logger)
+			case googledrive2hugo.IsSpreadsheet(j.info):
+				return exportSheet(src, j, logger)
+			case isStaticAsset(j.info):
+				return exportAsset(src, j, logger)
+			}
+			return export(src, convert, fetch, j, logger)
+		})
+
+		// report and record in walk order so output is the same
+		// no matter which worker finished first
+		failed := 0
+		for i, j := range todo {
+			if errs[i] != nil {
+				logger.Error("export failed", "path", j.origpath, "err", errs[i])
+				failed++
+				continue
+			}
+			if *flagOut != "" {
+				state.Update(j.info, j.outpath)
+				state.Files[j.info.Id].Draft = j.draft
+			}
+			written++
+		}
+		if failed > 0 {
+			err = fmt.Errorf("%d of %d docs failed", failed, len(todo))
+		}
+	}
+
+	for _, h := range heldBack {
+		logger.Info("not ready to publish", "path", h.path, "reason", h.reason, "action", *flagHeld)
+	}
+	if len(heldBack) > 0 {
+		logger.Info("held back docs", "count", len(heldBack))
+	}
+
+	if err == nil && walkErrs > 0 {
+		err = fmt.Errorf("walk incomplete, %d walk errors", walkErrs)
+	}
+
+	// save even if the export failed, so completed exports are not redone
+	if *flagState != "" {
+		if serr := state.Save(*flagState); serr != nil {
+			return watched, written, fmt.Errorf("unable to save state %q: %s", *flagState, serr)
+		}
+	}
+	return watched, written, err
 }
 
 func main() {
 	stdlog := log.New(os.Stderr, "", 0)
 	logger := adapter.New(stdlog)
 
+	flag.Parse()
+	if *flagZip && !*flagBundle {
+		log.Fatalf("-zip requires -bundle")
+	}
+	publish, err := googledrive2hugo.ParsePublishPolicy(*flagPublish)
+	if err != nil {
+		log.Fatalf("bad -publish: %s", err)
+	}
+	if *flagHeld != "skip" && *flagHeld != "draft" {
+		log.Fatalf("unknown -unpublished %q", *flagHeld)
+	}
+	switch *flagFormat {
+	case "html":
+	case "markdown":
+		bundleIndex = "index.md"
+		sectionIndex = "_index.md"
+	default:
+		log.Fatalf("unknown -format %q", *flagFormat)
+	}
+	if *flagWatch && *flagDryRun {
+		log.Fatalf("-watch and -dry-run can't be used together")
+	}
+	if *flagRevision != "head" && *flagRevision != "kept" {
+		log.Fatalf("unknown -revision %q", *flagRevision)
+	}
+	if !googledrive2hugo.IsDataFormat(*flagDataFmt) {
+		log.Fatalf("unknown -data-format %q", *flagDataFmt)
+	}
+	if !googledrive2hugo.IsFrontMatterFormat(*flagFront) {
+		log.Fatalf("unknown -front-matter %q", *flagFront)
+	}
+	if !googledrive2hugo.IsDrawingFormat(*flagDrawing) {
+		log.Fatalf("unknown -drawing-format %q", *flagDrawing)
+	}
+	if !googledrive2hugo.IsSlidesFormat(*flagSlides) {
+		log.Fatalf("unknown -slides-format %q", *flagSlides)
+	}
+
 	confbytes, err := ioutil.ReadFile(*flagConfig)
 	if err != nil {
-		log.Fatal("unable to read %q: %s", *flagConfig, err)
+		log.Fatalf("unable to read %q: %s", *flagConfig, err)
 	}
-	filter, err := googledrive2hugo.Parse(string(confbytes))
+	config, err := googledrive2hugo.ParseConfig(string(confbytes))
 	if err != nil {
-		log.Fatalf("unable to parse %q", *flagConfig, err)
+		log.Fatalf("unable to parse %q: %s", *flagConfig, err)
 	}
+	metaMap = config.Meta
 	convert := googledrive2hugo.Converter{
-		Logger: logger,
-		Filters: filter,
+		Logger:      logger,
+		Filters:     config.Filters,
+		FrontMatter: config.FrontMatter,
+	}
+	if *flagFront != "" {
+		convert.FrontMatter = *flagFront
 	}
 
-	srv, err := googledrive2hugo.Setup()
-	if err != nil {
-		logger.Error("unable to auth", "err", err)
-		os.Exit(1)
+	state := googledrive2hugo.NewState()
+	if *flagState != "" {
+		state, err = googledrive2hugo.LoadState(*flagState)
+		if err != nil {
+			logger.Error("unable to read state", "path", *flagState, "err", err)
+			os.Exit(1)
+		}
 	}
 
-	err = googledrive2hugo.Walk(srv, *flagRoot, walker(convert, logger))
-	if err != nil {
-		logger.Error("walk failed", "err", err)
+	var src googledrive2hugo.DriveSource
+	if *flagLocal != "" {
+		src = googledrive2hugo.NewLocalSource(*flagLocal)
+	} else {
+		client, err := googledrive2hugo.Client(authOptions()...)
+		if err != nil {
+			logger.Error("unable to auth", "err", err)
+			os.Exit(1)
+		}
+		srv, err := drive.New(client)
+		if err != nil {
+			logger.Error("unable to make drive service", "err", err)
+			os.Exit(1)
+		}
+		service := googledrive2hugo.NewDriveService(srv)
+		service.Client = client
+		if service.Slides, err = slides.New(client); err != nil {
+			logger.Error("unable to make slides service", "err", err)
+			os.Exit(1)
+		}
+		if *flagDrive != "" {
+			if err = service.UseSharedDrive(*flagDrive); err != nil {
+				logger.Error("unable to use shared drive", "drive", *flagDrive, "err", err)
+				os.Exit(1)
+			}
+		}
+		src = googledrive2hugo.NewThrottledSource(service, *flagRate, *flagJobs)
+	}
+
+	if *flagWatch {
+		watch(src, state, func() (map[string]bool, int, error) {
+			return syncDrive(src, convert, state, publish, logger)
+		}, logger)
+		return
+	}
+	if _, _, err = syncDrive(src, convert, state, publish, logger); err != nil {
+		logger.Error("export failed", "err", err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/client9/googledrive2hugo"
+	"github.com/client9/ilog"
+	"google.golang.org/api/drive/v3"
+)
+
+// testDrive copies testdata/drive to a temporary directory and points
+// the output and state flags there.  It returns the directory and a
+// func to clean up.
+func testDrive(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "gdoc-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = filepath.Walk("../../testdata/drive", func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel("../../testdata", path)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, rel), 0755)
+		}
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(dir, rel), raw, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, state, archive := *flagOut, *flagState, *flagArchive
+	*flagOut = filepath.Join(dir, "out")
+	*flagState = filepath.Join(dir, "state.json")
+	*flagArchive = ""
+	return dir, func() {
+		*flagOut, *flagState, *flagArchive = out, state, archive
+		os.RemoveAll(dir)
+	}
+}
+
+// testSync runs syncDrive like gdoc-export does, with the state read
+// from and saved to the state file
+func testSync(t *testing.T, src googledrive2hugo.DriveSource) (*googledrive2hugo.State, error) {
+	state, err := googledrive2hugo.LoadState(*flagState)
+	if err != nil {
+		t.Fatalf("unable to read state: %s", err)
+	}
+	publish, err := googledrive2hugo.ParsePublishPolicy("all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	convert := googledrive2hugo.Converter{Logger: &ilog.NopLogger{}}
+	_, _, err = syncDrive(src, convert, state, publish, &ilog.NopLogger{})
+	saved, lerr := googledrive2hugo.LoadState(*flagState)
+	if lerr != nil {
+		t.Fatalf("unable to read saved state: %s", lerr)
+	}
+	return saved, err
+}
+
+// statePaths maps each file in the state to its output, relative to
+// the output directory
+func statePaths(t *testing.T, state *googledrive2hugo.State) map[string]string {
+	out := make(map[string]string)
+	for id, f := range state.Files {
+		rel, err := filepath.Rel(*flagOut, f.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[id] = filepath.ToSlash(rel)
+	}
+	return out
+}
+
+func checkFiles(t *testing.T, root string, exist []string, missing []string) {
+	t.Helper()
+	for _, name := range exist {
+		if !fileExists(filepath.Join(root, name)) {
+			t.Errorf("expected %s", name)
+		}
+	}
+	for _, name := range missing {
+		if fileExists(filepath.Join(root, name)) {
+			t.Errorf("expected no %s", name)
+		}
+	}
+}
+
+// renameSource renames files by ID, as if renamed in Drive
+type renameSource struct {
+	*googledrive2hugo.LocalSource
+	names map[string]string
+}
+
+func (r *renameSource) List(folder *drive.File) ([]*drive.File, error) {
+	files, err := r.LocalSource.List(folder)
+	for _, f := range files {
+		if name, ok := r.names[f.Id]; ok {
+			f.Name = name
+		}
+	}
+	return files, err
+}
+
+// failSource fails to list or export the files with the given IDs
+type failSource struct {
+	*googledrive2hugo.LocalSource
+	list   string
+	export string
+}
+
+func (f *failSource) List(folder *drive.File) ([]*drive.File, error) {
+	if folder.Id == f.list {
+		return nil, errors.New("list failed")
+	}
+	return f.LocalSource.List(folder)
+}
+
+func (f *failSource) Export(file *drive.File, mimeType string) ([]byte, error) {
+	if file.Id == f.export {
+		return nil, errors.New("export failed")
+	}
+	return f.LocalSource.Export(file, mimeType)
+}
+
+func TestSyncDrive(t *testing.T) {
+	dir, cleanup := testDrive(t)
+	defer cleanup()
+	local := googledrive2hugo.NewLocalSource(filepath.Join(dir, "drive"))
+
+	state, err := testSync(t, local)
+	if err != nil {
+		t.Fatalf("sync failed: %s", err)
+	}
+	want := map[string]string{
+		"about.html":             "about.html",
+		"posts":                  "posts/_index.html",
+		"posts/hello-world.html": "posts/hello-world.html",
+	}
+	if got := statePaths(t, state); !reflect.DeepEqual(got, want) {
+		t.Errorf("state: want %v got %v", want, got)
+	}
+	checkFiles(t, *flagOut, []string{"about.html", "posts/_index.html", "posts/hello-world.html"}, nil)
+
+	// renamed in drive: the output is moved, not exported again
+	renamed := &renameSource{
+		LocalSource: local,
+		names:       map[string]string{"posts/hello-world.html": "hello-again"},
+	}
+	if state, err = testSync(t, renamed); err != nil {
+		t.Fatalf("sync failed: %s", err)
+	}
+	want["posts/hello-world.html"] = "posts/hello-again.html"
+	if got := statePaths(t, state); !reflect.DeepEqual(got, want) {
+		t.Errorf("state after rename: want %v got %v", want, got)
+	}
+	checkFiles(t, *flagOut, []string{"posts/hello-again.html"}, []string{"posts/hello-world.html"})
+
+	// deleted in drive: the output is removed
+	if err = os.Remove(filepath.Join(dir, "drive", "about.html")); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = testSync(t, renamed); err != nil {
+		t.Fatalf("sync failed: %s", err)
+	}
+	delete(want, "about.html")
+	if got := statePaths(t, state); !reflect.DeepEqual(got, want) {
+		t.Errorf("state after delete: want %v got %v", want, got)
+	}
+	checkFiles(t, *flagOut, nil, []string{"about.html"})
+
+	// with -archive the output is moved there instead
+	*flagArchive = filepath.Join(dir, "archive")
+	if err = os.Remove(filepath.Join(dir, "drive", "posts", "hello-world.html")); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = testSync(t, renamed); err != nil {
+		t.Fatalf("sync failed: %s", err)
+	}
+	delete(want, "posts/hello-world.html")
+	if got := statePaths(t, state); !reflect.DeepEqual(got, want) {
+		t.Errorf("state after archive: want %v got %v", want, got)
+	}
+	checkFiles(t, *flagOut, nil, []string{"posts/hello-again.html"})
+	checkFiles(t, *flagArchive, []string{"posts/hello-again.html"}, nil)
+}
+
+func TestSyncDriveFailed(t *testing.T) {
+	dir, cleanup := testDrive(t)
+	defer cleanup()
+	local := googledrive2hugo.NewLocalSource(filepath.Join(dir, "drive"))
+
+	// a doc that fails is left out of the state, the others are kept
+	if _, err := testSync(t, &failSource{LocalSource: local, export: "about.html"}); err == nil {
+		t.Fatalf("expected export to fail")
+	}
+	state, err := googledrive2hugo.LoadState(*flagState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"posts":                  "posts/_index.html",
+		"posts/hello-world.html": "posts/hello-world.html",
+	}
+	if got := statePaths(t, state); !reflect.DeepEqual(got, want) {
+		t.Errorf("state: want %v got %v", want, got)
+	}
+
+	// a folder that can't be listed fails the sync, and nothing under
+	// it is removed
+	state, err = testSync(t, &failSource{LocalSource: local, list: "posts"})
+	if err == nil {
+		t.Fatalf("expected walk to fail")
+	}
+	want["about.html"] = "about.html"
+	if got := statePaths(t, state); !reflect.DeepEqual(got, want) {
+		t.Errorf("state after walk error: want %v got %v", want, got)
+	}
+	checkFiles(t, *flagOut, []string{"about.html", "posts/_index.html", "posts/hello-world.html"}, nil)
+}
+
+func TestRunJobsOrder(t *testing.T) {
+	jobs := []*job{{origpath: "a"}, {origpath: "b"}, {origpath: "c"}}
+	delay := map[string]time.Duration{"a": 20 * time.Millisecond, "b": 10 * time.Millisecond}
+	errs := runJobs(3, jobs, func(j *job) error {
+		time.Sleep(delay[j.origpath])
+		return errors.New(j.origpath)
+	})
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+}
+
+func TestAnyWatched(t *testing.T) {
+	watched := map[string]bool{"doc": true, "folder": true}
+	cases := []struct {
+		change *drive.Change
+		want   bool
+	}{
+		{&drive.Change{FileId: "doc"}, true},
+		{&drive.Change{FileId: "new", File: &drive.File{Parents: []string{"folder"}}}, true},
+		{&drive.Change{FileId: "other", File: &drive.File{Parents: []string{"elsewhere"}}}, false},
+		{&drive.Change{FileId: "gone", Removed: true}, false},
+	}
+	for _, tt := range cases {
+		if got := anyWatched([]*drive.Change{tt.change}, watched); got != tt.want {
+			t.Errorf("%s: want %v got %v", tt.change.FileId, tt.want, got)
+		}
+	}
+}
+
+func TestLinkSections(t *testing.T) {
+	folder := &drive.File{Id: "folder", MimeType: "application/vnd.google-apps.folder"}
+	index := &drive.File{Id: "index", Name: googledrive2hugo.SectionIndex, MimeType: "application/vnd.google-apps.document"}
+	jobs := []*job{
+		{outpath: "posts/_index.html", info: folder},
+		{outpath: "posts/_index.html", info: index},
+		{outpath: "other/_index.html", info: &drive.File{Id: "other", MimeType: folder.MimeType}},
+	}
+	jobs = linkSections(jobs)
+	if len(jobs) != 2 || jobs[0].info != index || jobs[1].info.Id != "other" {
+		t.Fatalf("unexpected jobs %v", jobs)
+	}
+	if jobs[0].section != folder {
+		t.Errorf("expected the index doc to have the folder as its section")
+	}
+}
+
+// testChanger is a changes feed where every read returns one change
+// to "doc", and the next token is one more
+type testChanger struct {
+	googledrive2hugo.DriveSource
+}
+
+func (c *testChanger) StartPageToken() (string, error) {
+	return "1", nil
+}
+
+func (c *testChanger) Changes(token string) ([]*drive.Change, string, error) {
+	return []*drive.Change{{FileId: "doc"}}, token + "+", nil
+}
+
+// the token is only saved once a sync succeeds, and a failed sync is
+// retried on the next poll even if nothing watched changed
+func TestWatcher(t *testing.T) {
+	_, cleanup := testDrive(t)
+	defer cleanup()
+
+	results := []error{errors.New("first sync failed"), nil, nil}
+	calls := 0
+	w := &watcher{
+		changer: &testChanger{},
+		state:   googledrive2hugo.NewState(),
+		logger:  &ilog.NopLogger{},
+		sync: func() (map[string]bool, int, error) {
+			err := results[calls]
+			calls++
+			return map[string]bool{"other": true}, 0, err
+		},
+	}
+	saved := func() string {
+		state, err := googledrive2hugo.LoadState(*flagState)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return state.PageToken
+	}
+
+	if err := w.start(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || w.synced || saved() != "" {
+		t.Fatalf("after failed sync: calls %d, synced %v, saved %q", calls, w.synced, saved())
+	}
+
+	w.poll()
+	if calls != 2 || !w.synced || w.token != "1+" || saved() != "1+" {
+		t.Fatalf("after retry: calls %d, synced %v, token %q, saved %q", calls, w.synced, w.token, saved())
+	}
+
+	// "doc" is no longer watched, so no sync
+	w.poll()
+	if calls != 2 || w.token != "1++" || saved() != "1++" {
+		t.Errorf("after unwatched change: calls %d, token %q, saved %q", calls, w.token, saved())
+	}
+}
+
+// a doc held back as a draft stays one, even if it says draft: false
+func TestExportDraft(t *testing.T) {
+	dir, cleanup := testDrive(t)
+	defer cleanup()
+
+	doc := `<html><body><p>---</p><p>draft: false</p><p>---</p><p>not ready</p></body></html>`
+	if err := ioutil.WriteFile(filepath.Join(dir, "drive", "wip.html"), []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	local := googledrive2hugo.NewLocalSource(filepath.Join(dir, "drive"))
+	info, err := local.Get("wip.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := &job{
+		origpath: "wip",
+		outpath:  filepath.Join(*flagOut, "wip.html"),
+		info:     info,
+		draft:    true,
+	}
+	convert := googledrive2hugo.Converter{Logger: &ilog.NopLogger{}}
+	if err = export(local, convert, nil, j, &ilog.NopLogger{}); err != nil {
+		t.Fatalf("export failed: %s", err)
+	}
+	out, err := ioutil.ReadFile(j.outpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "draft: true\n") {
+		t.Errorf("expected draft: true in\n%s", out)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"sync"
+)
+
+// runJobs calls fn on each job using n workers.  The errors are
+// returned in the same order as jobs, no matter what order the
+// workers finish in.
+func runJobs(n int, jobs []*job, fn func(*job) error) []error {
+	if n < 1 {
+		n = 1
+	}
+	errs := make([]error, len(jobs))
+	next := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range next {
+				errs[idx] = fn(jobs[idx])
+			}
+		}()
+	}
+	for idx := range jobs {
+		next <- idx
+	}
+	close(next)
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/client9/googledrive2hugo"
+	"google.golang.org/api/drive/v3"
+)
+
+var errNoRevision = fmt.Errorf("no kept revision")
+
+// pickRevisions picks the revision of each doc to export: the one
+// pinned in the state file, or with -revision kept, the latest kept
+// revision.  The doc's modified time becomes that of the revision, so
+// it is only exported again when a new revision is picked.
+//
+// Docs with no kept revision are not ready to publish.  They are
+// skipped, or exported at the head revision as drafts.
+func pickRevisions(src googledrive2hugo.DriveSource, state *googledrive2hugo.State, jobs []*job, heldBack *[]held) ([]*job, error) {
+	errs := runJobs(*flagJobs, jobs, func(j *job) error {
+		if !googledrive2hugo.IsGoogleDoc(j.info) || j.draft {
+			return nil
+		}
+		pin := state.Revision(j.info.Id)
+		if pin == "" && *flagRevision != "kept" {
+			return nil
+		}
+		rev, err := findRevision(src, j.info, pin)
+		if err != nil {
+			return err
+		}
+		if rev == nil {
+			return errNoRevision
+		}
+		info := *j.info
+		info.ModifiedTime = rev.ModifiedTime
+		j.info = &info
+		j.revision = rev.Id
+		return nil
+	})
+	out := jobs[:0]
+	for i, j := range jobs {
+		switch {
+		case errs[i] == errNoRevision:
+			*heldBack = append(*heldBack, held{path: j.origpath, reason: "no kept revision"})
+			if *flagHeld == "skip" {
+				continue
+			}
+			j.draft = true
+		case errs[i] != nil:
+			return nil, fmt.Errorf("%s: %s", j.origpath, errs[i])
+		}
+		out = append(out, j)
+	}
+	return out, nil
+}
+
+// findRevision returns the revision with ID pin, or if pin is empty the
+// latest kept revision
+func findRevision(src googledrive2hugo.DriveSource, f *drive.File, pin string) (*drive.Revision, error) {
+	if pin == "" {
+		return googledrive2hugo.KeptRevision(src, f)
+	}
+	reviser, ok := src.(googledrive2hugo.Reviser)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support revisions", src)
+	}
+	revs, err := reviser.Revisions(f)
+	if err != nil {
+		return nil, err
+	}
+	for _, rev := range revs {
+		if rev.Id == pin {
+			return rev, nil
+		}
+	}
+	return nil, fmt.Errorf("pinned revision %q not found", pin)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/client9/googledrive2hugo"
+	"github.com/client9/ilog"
+	"google.golang.org/api/drive/v3"
+)
+
+// section page, "_index.md" with -format markdown
+var sectionIndex = "_index.html"
+
+// sectionMeta is the front matter of the section page of a folder
+func sectionMeta(folder *drive.File) map[string]interface{} {
+	return googledrive2hugo.MetaMerge(metaMap.Meta(folder), googledrive2hugo.SectionMeta(folder))
+}
+
+// sectionIndexMeta is the front matter the folder gives to its
+// "_index" doc.  The dates are those of the doc.
+func sectionIndexMeta(folder *drive.File) map[string]interface{} {
+	meta := sectionMeta(folder)
+	delete(meta, "date")
+	delete(meta, "lastmod")
+	return meta
+}
+
+// linkSections replaces the section page of a folder that has an
+// "_index" doc with the doc
+func linkSections(jobs []*job) []*job {
+	docs := make(map[string]*job)
+	for _, j := range jobs {
+		if googledrive2hugo.IsSectionIndex(j.info) {
+			docs[j.outpath] = j
+		}
+	}
+	out := jobs[:0]
+	for _, j := range jobs {
+		if googledrive2hugo.IsDir(j.info) {
+			if doc, ok := docs[j.outpath]; ok {
+				doc.section = j.info
+				continue
+			}
+		}
+		out = append(out, j)
+	}
+	return out
+}
+
+// exportSection writes the section page of a folder without an
+// "_index" doc.  It only has front matter, in format.
+func exportSection(j *job, format string, logger ilog.Logger) error {
+	out, err := googledrive2hugo.HugoContentWrite(nil, sectionMeta(j.info), format)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(j.outpath), 0755); err != nil {
+		return err
+	}
+	logger.Debug("writing section", "path", j.outpath)
+	return googledrive2hugo.WriteFileAtomic(j.outpath, out, 0644)
+}
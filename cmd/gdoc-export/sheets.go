@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/client9/googledrive2hugo"
+	"github.com/client9/ilog"
+)
+
+// isDataDir is true if outpath is the directory of data files
+// written for a sheet
+func isDataDir(outpath string) bool {
+	fi, err := os.Stat(outpath)
+	return err == nil && fi.IsDir()
+}
+
+// exportSheet writes each tab of a sheet as a data file in the
+// directory j.outpath.  Files for tabs that no longer exist are removed.
+func exportSheet(src googledrive2hugo.DriveSource, j *job, logger ilog.Logger) error {
+	logger.Debug("reading sheet", "path", j.origpath)
+	sheets, err := googledrive2hugo.ExportSheets(src, j.info)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(j.outpath, 0755); err != nil {
+		return err
+	}
+	written := make(map[string]bool)
+	for _, sheet := range sheets {
+		var buf bytes.Buffer
+		if err = sheet.Write(&buf, *flagDataFmt); err != nil {
+			return err
+		}
+		path := filepath.Join(j.outpath, googledrive2hugo.URLize(sheet.Name)+"."+*flagDataFmt)
+		logger.Debug("writing data", "path", path)
+		if err = googledrive2hugo.WriteFileAtomic(path, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		written[path] = true
+	}
+	files, err := dataFiles(j.outpath)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if !written[path] {
+			if err = os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dataFiles lists the data files written for a sheet
+func dataFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []string
+	for _, fi := range entries {
+		ext := filepath.Ext(fi.Name())
+		if !fi.IsDir() && ext != "" && googledrive2hugo.IsDataFormat(ext[1:]) {
+			out = append(out, filepath.Join(dir, fi.Name()))
+		}
+	}
+	return out, nil
+}
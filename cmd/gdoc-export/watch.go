@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/client9/googledrive2hugo"
+	"github.com/client9/ilog"
+	"google.golang.org/api/drive/v3"
+)
+
+// watch runs sync, then polls the Drive changes feed and runs sync
+// again whenever a file or folder seen in the last walk changed, or a
+// file was added to one of the folders.  Until a sync succeeds it is
+// run again on every poll.  The position in the feed is kept in the
+// state file, and only moves on after a sync succeeds.  It only
+// returns if the source has no changes feed.
+func watch(src googledrive2hugo.DriveSource, state *googledrive2hugo.State, sync func() (map[string]bool, int, error), logger ilog.Logger) {
+	changer, ok := src.(googledrive2hugo.Changer)
+	if !ok {
+		logger.Error("watch mode needs the google drive changes feed, not -local")
+		os.Exit(1)
+	}
+	w := &watcher{
+		changer: changer,
+		state:   state,
+		sync:    sync,
+		logger:  logger,
+	}
+	if err := w.start(); err != nil {
+		logger.Error("unable to read changes", "err", err)
+		os.Exit(1)
+	}
+	for {
+		time.Sleep(*flagInterval)
+		w.poll()
+	}
+}
+
+// watcher is the state of watch mode between polls
+type watcher struct {
+	changer googledrive2hugo.Changer
+	state   *googledrive2hugo.State
+	sync    func() (map[string]bool, int, error)
+	logger  ilog.Logger
+
+	token   string          // where to read the changes feed from
+	watched map[string]bool // files and folders seen in the last walk
+	synced  bool            // the last sync succeeded
+}
+
+// start runs the first sync.  The token is read first, so changes made
+// during the sync are seen.
+func (w *watcher) start() error {
+	w.token = w.state.PageToken
+	if w.token == "" {
+		token, err := w.changer.StartPageToken()
+		if err != nil {
+			return err
+		}
+		w.token = token
+	}
+	w.run(w.token)
+	return nil
+}
+
+// poll reads the changes feed, and runs sync if anything watched
+// changed or the last sync failed
+func (w *watcher) poll() {
+	changes, next, err := w.changer.Changes(w.token)
+	if err != nil {
+		w.logger.Error("unable to read changes", "err", err)
+		return
+	}
+	if w.synced && !anyWatched(changes, w.watched) {
+		w.token = next
+		w.saveToken()
+		return
+	}
+	if w.synced {
+		w.logger.Info("drive changed", "changes", len(changes))
+	}
+	w.run(next)
+}
+
+// run runs sync, and if it succeeds moves the position in the changes
+// feed on to next.  If it fails the same changes are read again on the
+// next poll.
+func (w *watcher) run(next string) {
+	watched, written, err := w.sync()
+	w.synced = err == nil
+	if err != nil {
+		w.logger.Error("export failed, retrying", "err", err)
+	} else {
+		w.token = next
+		w.watched = watched
+		w.saveToken()
+	}
+	runHook(written, w.logger)
+}
+
+// saveToken records the position in the changes feed in the state file
+func (w *watcher) saveToken() {
+	w.state.PageToken = w.token
+	if *flagState == "" {
+		return
+	}
+	if err := w.state.Save(*flagState); err != nil {
+		w.logger.Error("unable to save state", "path", *flagState, "err", err)
+	}
+}
+
+// anyWatched is true if a change is to a watched file or folder, or
+// to a file in a watched folder
+func anyWatched(changes []*drive.Change, watched map[string]bool) bool {
+	for _, c := range changes {
+		if watched[c.FileId] {
+			return true
+		}
+		if c.File == nil {
+			continue
+		}
+		for _, parent := range c.File.Parents {
+			if watched[parent] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runHook runs the -hook command if anything was written
+func runHook(written int, logger ilog.Logger) {
+	if *flagHook == "" || written == 0 {
+		return
+	}
+	logger.Debug("running hook", "command", *flagHook)
+	cmd := exec.Command("sh", "-c", *flagHook)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		logger.Error("hook failed", "command", *flagHook, "err", err)
+	}
+}
@@ -21,12 +21,77 @@ narrow-tags
 check-punc
 `
 
-func Parse(text string) ([]Runner, error) {
-	root := &conf{}
+// Config is a parsed config file
+type Config struct {
+	// Filters are run on every doc, in order.  They include the
+	// built-in passes, see GdocTransform.
+	Filters []Runner
+
+	// Meta maps Drive file fields to front matter, set with
+	//
+	//	front-matter author owner
+	//	front-matter description     # don't set description
+	Meta MetaMap
+
+	// FrontMatter is the format of the front matter, set with
+	//
+	//	front-matter-format toml
+	FrontMatter string
+}
+
+// ParseConfig parses a config file
+func ParseConfig(text string) (*Config, error) {
+	root := &conf{
+		Meta:     NewMetaMap(),
+		Disabled: make(map[string]bool),
+		Tuned:    make(map[string]*GdocTransform),
+	}
 	if err := shconfig.Parse(root, text); err != nil {
 		return nil, err
 	}
-	return root.Runner, nil
+	filters := root.Runner
+	if !hasGdocTransform(filters) {
+		filters = append(DefaultTransforms(), filters...)
+	}
+	listed := make(map[string]bool)
+	for i, f := range filters {
+		t, ok := f.(*GdocTransform)
+		if !ok {
+			continue
+		}
+		if tuned, ok := root.Tuned[t.Name]; ok {
+			t = tuned
+		}
+		t.Disabled = root.Disabled[t.Name]
+		filters[i] = t
+		listed[t.Name] = true
+	}
+	for name := range root.Tuned {
+		if !listed[name] {
+			return nil, fmt.Errorf("%s is tuned but not listed, so it doesn't run", name)
+		}
+	}
+	return &Config{
+		Filters:     filters,
+		Meta:        root.Meta,
+		FrontMatter: root.FrontMatter,
+	}, nil
+}
+
+// Parse parses a config file, returning only the filters, without the
+// built-in passes the Converter runs by default
+func Parse(text string) ([]Runner, error) {
+	config, err := ParseConfig(text)
+	if err != nil {
+		return nil, err
+	}
+	out := []Runner{}
+	for _, f := range config.Filters {
+		if _, ok := f.(*GdocTransform); !ok {
+			out = append(out, f)
+		}
+	}
+	return out, nil
 }
 
 var confmap = map[string]func([]string) (Runner, error){
@@ -36,6 +101,15 @@ var confmap = map[string]func([]string) (Runner, error){
 	"unsmart-code":      configUnsmartCode,
 	"narrow-tags":       configNarrowTags,
 	"check-punc":        configCheckPunc,
+
+	// built-in passes, see GdocTransform
+	"gdoc-img":            configGdocTransform,
+	"gdoc-span":           configGdocTransform,
+	"gdoc-blockquote-pre": configGdocTransform,
+	"gdoc-blockquote":     configGdocTransform,
+	"gdoc-codeblock":      configGdocTransform,
+	"gdoc-table":          configGdocTransform,
+	"gdoc-attr":           configGdocTransform,
 }
 
 func configAddClass(args []string) (Runner, error) {
@@ -75,10 +149,24 @@ func configLinkRelative(args []string) (Runner, error) {
 }
 
 type conf struct {
-	Runner []Runner
+	Runner      []Runner
+	Meta        MetaMap
+	FrontMatter string
+	Disabled    map[string]bool
+
+	// Tuned are the built-in passes given arguments
+	Tuned map[string]*GdocTransform
 }
 
 func (r *conf) ConfCall(args []string) error {
+	switch args[0] {
+	case "front-matter":
+		return r.confFrontMatter(args)
+	case "front-matter-format":
+		return r.confFrontMatterFormat(args)
+	case "disable":
+		return r.confDisable(args)
+	}
 	fn, ok := confmap[args[0]]
 	if !ok {
 		return fmt.Errorf("command %q not found", args[0])
@@ -87,12 +175,48 @@ func (r *conf) ConfCall(args []string) error {
 	if err != nil {
 		return err
 	}
+	if t, ok := check.(*GdocTransform); ok && len(args) > 1 {
+		r.Tuned[t.Name] = t
+		return nil
+	}
 	if check != nil {
 		r.Runner = append(r.Runner, check)
 	}
 	return nil
 }
 
+func (r *conf) confFrontMatter(args []string) error {
+	switch len(args) {
+	case 2:
+		return r.Meta.Set(args[1], "")
+	case 3:
+		return r.Meta.Set(args[1], args[2])
+	}
+	return fmt.Errorf("%s: expected key and drive field", args[0])
+}
+
+func (r *conf) confFrontMatterFormat(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%s: expected yaml, toml or json", args[0])
+	}
+	if !IsFrontMatterFormat(args[1]) {
+		return fmt.Errorf("%s: unknown format %q", args[0], args[1])
+	}
+	r.FrontMatter = args[1]
+	return nil
+}
+
+func (r *conf) confDisable(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%s: expected name of a gdoc transform", args[0])
+	}
+	if !IsGdocTransform(args[1]) {
+		return fmt.Errorf("%s: unknown transform %q", args[0], args[1])
+	}
+	r.Disabled[args[1]] = true
+	return nil
+}
+
 func (r *conf) ConfObject(args []string) (shconfig.Dispatcher, error) {
 	return nil, fmt.Errorf("no config objects")
 }
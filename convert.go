@@ -3,6 +3,7 @@ package googledrive2hugo
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/client9/ilog"
@@ -19,35 +20,82 @@ func removeNbsp(src string) string {
 type Converter struct {
 	Logger  ilog.Logger
 	Filters []Runner
+
+	// Transforms are the built-in passes cleaning up Google Docs HTML,
+	// run before Filters.  Nil runs DefaultTransforms, unless Filters
+	// has built-in passes already, as from ParseConfig.  An empty list
+	// runs none.
+	Transforms []Runner
+
+	// Meta is front matter that overrides both the front matter in
+	// the doc and the file meta
+	Meta map[string]interface{}
+
+	// FrontMatter is the format of the front matter: "yaml" (the
+	// default), "toml" or "json"
+	FrontMatter string
 }
 
 func (c *Converter) ToHTML(src []byte, fileMeta map[string]interface{}) ([]byte, error) {
+	out, _, err := c.convert(src, fileMeta, nil, renderHTML)
+	return out, err
+}
+
+// ToMarkdown is like ToHTML, but writes the content as Markdown, for
+// Hugo's Goldmark renderer
+func (c *Converter) ToMarkdown(src []byte, fileMeta map[string]interface{}) ([]byte, error) {
+	out, _, err := c.convert(src, fileMeta, nil, renderMarkdown)
+	return out, err
+}
+
+// ToBundle is like ToHTML, but also fetches every image in the doc.
+// The <img src> are rewritten to the names of the returned images, so
+// the output can be written as a Hugo leaf bundle: index.html with the
+// images next to it.
+func (c *Converter) ToBundle(src []byte, fileMeta map[string]interface{}, fetch ImageFetcher) ([]byte, map[string][]byte, error) {
+	return c.convert(src, fileMeta, fetch, renderHTML)
+}
+
+// ToMarkdownBundle is like ToBundle, but writes the content as
+// Markdown
+func (c *Converter) ToMarkdownBundle(src []byte, fileMeta map[string]interface{}, fetch ImageFetcher) ([]byte, map[string][]byte, error) {
+	return c.convert(src, fileMeta, fetch, renderMarkdown)
+}
+
+func (c *Converter) convert(src []byte, fileMeta map[string]interface{}, fetch ImageFetcher, render renderFunc) ([]byte, map[string][]byte, error) {
 	root, err := html.Parse(bytes.NewReader(src))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	content, textMeta, err := c.FromNode(getBody(root))
+	content, textMeta, images, err := c.fromNode(getBody(root), fetch, render)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	meta := MetaMerge(textMeta, fileMeta)
+	for k, v := range c.Meta {
+		meta[k] = v
+	}
 
 	// generate some extra tags for rollup or archives
 	value, ok := meta["date"]
 	if !ok {
-		return nil, fmt.Errorf("unable to get document date in %s", string(src))
+		return nil, nil, fmt.Errorf("unable to get document date in %s", string(src))
 	}
 	date, err := cast.ToTimeE(value)
 	if err != nil {
-		return nil, fmt.Errorf("unable convert date '%T' %v to time.Time", value, value)
+		return nil, nil, fmt.Errorf("unable convert date '%T' %v to time.Time", value, value)
 	}
 	meta["year"] = fmt.Sprintf("%d", date.Year())
 	meta["month"] = fmt.Sprintf("%d/%02d", date.Year(), date.Month())
 	meta["day"] = fmt.Sprintf("%d/%02d/%02d", date.Year(), date.Month(), date.Day())
 
-	return HugoContentWrite(content, meta)
+	out, err := HugoContentWrite(content, meta, c.FrontMatter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, images, nil
 }
 
 func (c *Converter) parseFragment(src string) (string, error) {
@@ -68,53 +116,74 @@ func (c *Converter) parseFragment(src string) (string, error) {
 
 // if you already have a google doc node
 func (c *Converter) FromNode(root *html.Node) ([]byte, map[string]interface{}, error) {
+	content, meta, _, err := c.fromNode(root, nil, renderHTML)
+	return content, meta, err
+}
+
+// renderFunc writes the children of the transformed tree
+type renderFunc func(w io.Writer, root *html.Node) error
+
+// renderHTML writes the children as HTML, with shortcodes and
+// entities unescaped
+func renderHTML(w io.Writer, root *html.Node) error {
+	buf := bytes.Buffer{}
+	if err := renderChildren(&buf, root); err != nil {
+		return err
+	}
+	out := buf.Bytes()
+
+	// final hugo fixups.. needed to be done outside of tree
+	out = unescapeShortcodes(out)
+	out = unescapeEntities(out)
+	_, err := w.Write(out)
+	return err
+}
+
+// fromNode transforms and renders the tree.  If fetch is not nil,
+// images are fetched and returned.
+func (c *Converter) fromNode(root *html.Node, fetch ImageFetcher, render renderFunc) ([]byte, map[string]interface{}, map[string][]byte, error) {
 	// hugo specific
 	meta, err := HugoFrontMatter(root)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	// generic transforms
-	tx := []func(*html.Node) error{
-		// gdoc specific
-		GdocImg,
-		GdocSpan,
-		GdocBlockquotePre,
-		GdocBlockquote,
-		GdocCodeBlock,
-		GdocTable,
-		GdocAttr,
+	// gdoc specific transforms, then the generic ones
+	tx := c.Transforms
+	if tx == nil && !hasGdocTransform(c.Filters) {
+		tx = DefaultTransforms()
 	}
+	tx = append(append([]Runner{}, tx...), c.Filters...)
 
 	for _, fn := range tx {
-		if err := fn(root); err != nil {
-			return nil, nil, err
-		}
-	}
-
-	for _, fn := range c.Filters {
 
 		// get name of function
 		fname := fmt.Sprintf("%T", fn)
 		if idx := strings.LastIndexByte(fname, '.'); idx != -1 {
 			fname = fname[idx+1:]
 		}
+		if t, ok := fn.(*GdocTransform); ok {
+			fname = t.Name
+		}
 
 		mlog := c.Logger.With("fn", fname)
 		if err := fn.Run(root, mlog); err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
+
+	var images map[string][]byte
+	if fetch != nil {
+		if images, err = bundleImages(root, fetch); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
 	// Render into buffer
 	buf := bytes.Buffer{}
-	if err := renderChildren(&buf, root); err != nil {
-		return nil, nil, err
+	if err := render(&buf, root); err != nil {
+		return nil, nil, nil, err
 	}
-	out := buf.Bytes()
-
-	// final hugo fixups.. needed to be done outside of tree
-	out = unescapeShortcodes(out)
-	out = unescapeEntities(out)
-	out = bytes.TrimSpace(out)
-	return out, meta, nil
+	out := bytes.TrimSpace(buf.Bytes())
+	return out, meta, images, nil
 }
@@ -0,0 +1,156 @@
+package googledrive2hugo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// MetaMap maps Hugo front matter keys to Drive file fields.  Fields
+// are
+//
+//	id, name, description, createdTime, modifiedTime, webViewLink,
+//	starred, owner (name of first owner), owners (names of all owners),
+//	lastModifyingUser, properties.<key>, appProperties.<key>
+//
+// For example {"author": "owner", "editUrl": "webViewLink",
+// "slug": "properties.slug"}.  Properties only become front matter
+// when mapped like this.
+type MetaMap map[string]string
+
+// DefaultMetaMap is the front matter always set from the file
+var DefaultMetaMap = MetaMap{
+	"date":        "createdTime",
+	"lastmod":     "modifiedTime",
+	"description": "description",
+}
+
+// NewMetaMap returns a copy of DefaultMetaMap
+func NewMetaMap() MetaMap {
+	m := make(MetaMap, len(DefaultMetaMap))
+	for k, v := range DefaultMetaMap {
+		m[k] = v
+	}
+	return m
+}
+
+// Set maps key to field, or if field is "" stops setting key
+func (m MetaMap) Set(key string, field string) error {
+	if field == "" {
+		delete(m, key)
+		return nil
+	}
+	if !IsMetaField(field) {
+		return fmt.Errorf("unknown drive field %q", field)
+	}
+	m[key] = field
+	return nil
+}
+
+// IsMetaField reports whether field can be used in a MetaMap
+func IsMetaField(field string) bool {
+	switch field {
+	case "id", "name", "description", "createdTime", "modifiedTime",
+		"webViewLink", "starred", "owner", "owners", "lastModifyingUser":
+		return true
+	}
+	return strings.HasPrefix(field, "properties.") || strings.HasPrefix(field, "appProperties.")
+}
+
+// Meta returns the front matter for a file, from the mapped fields
+func (m MetaMap) Meta(f *drive.File) map[string]interface{} {
+	meta := make(map[string]interface{})
+	for key, field := range m {
+		if v := metaField(f, key, field); v != nil {
+			meta[key] = v
+		}
+	}
+	return meta
+}
+
+// metaField returns the value of a field for front matter key, or nil
+// if it is not set
+func metaField(f *drive.File, key string, field string) interface{} {
+	var s string
+	switch field {
+	case "id":
+		s = f.Id
+	case "name":
+		s = f.Name
+	case "description":
+		s = f.Description
+	case "createdTime":
+		s = f.CreatedTime
+	case "modifiedTime":
+		s = f.ModifiedTime
+	case "webViewLink":
+		s = f.WebViewLink
+	case "starred":
+		return f.Starred
+	case "owner":
+		if len(f.Owners) > 0 {
+			s = f.Owners[0].DisplayName
+		}
+	case "owners":
+		var names []string
+		for _, u := range f.Owners {
+			names = append(names, u.DisplayName)
+		}
+		if len(names) > 0 {
+			return names
+		}
+	case "lastModifyingUser":
+		if f.LastModifyingUser != nil {
+			s = f.LastModifyingUser.DisplayName
+		}
+	default:
+		if strings.HasPrefix(field, "properties.") {
+			if v, ok := f.Properties[field[len("properties."):]]; ok {
+				return PropertyValue(key, v)
+			}
+		}
+		if strings.HasPrefix(field, "appProperties.") {
+			if v, ok := f.AppProperties[field[len("appProperties."):]]; ok {
+				return PropertyValue(key, v)
+			}
+		}
+	}
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// PropertyValue types a Drive property, which is always a string, as
+// the value of front matter key: "true" and "false" are booleans, a
+// weight is a number, and aliases, tags, categories and keywords are
+// lists of strings written "[a, b]".  Anything else stays a string, so
+// a slug of "007" is not the number 7.
+func PropertyValue(key string, s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	switch key {
+	case "weight":
+		if i, err := strconv.Atoi(s); err == nil {
+			return i
+		}
+	case "aliases", "tags", "categories", "keywords":
+		if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+			break
+		}
+		list := []string{}
+		for _, item := range strings.Split(s[1:len(s)-1], ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+	return s
+}
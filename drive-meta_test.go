@@ -0,0 +1,60 @@
+package googledrive2hugo
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+)
+
+func TestMetaMap(t *testing.T) {
+	config, err := ParseConfig(`
+front-matter author owner
+front-matter editUrl webViewLink
+front-matter description
+front-matter slug properties.slug
+front-matter draft properties.draft
+front-matter weight appProperties.weight
+front-matter aliases properties.aliases
+front-matter title properties.title
+`)
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err)
+	}
+	f := &drive.File{
+		Name:         "Hello World",
+		CreatedTime:  "2019-01-02T03:04:05Z",
+		ModifiedTime: "2019-02-03T04:05:06Z",
+		Description:  "not used",
+		WebViewLink:  "https://docs.google.com/document/d/abc/edit",
+		Owners:       []*drive.User{{DisplayName: "Jane Doe"}},
+		AppProperties: map[string]string{
+			"weight": "10",
+		},
+		Properties: map[string]string{
+			"slug":    "007",
+			"title":   "2024",
+			"draft":   "true",
+			"aliases": "[/old, /older]",
+			"ready":   "true",
+		},
+	}
+	want := map[string]interface{}{
+		"date":    "2019-01-02T03:04:05Z",
+		"lastmod": "2019-02-03T04:05:06Z",
+		"author":  "Jane Doe",
+		"editUrl": "https://docs.google.com/document/d/abc/edit",
+		"slug":    "007",
+		"title":   "2024",
+		"draft":   true,
+		"weight":  10,
+		"aliases": []string{"/old", "/older"},
+	}
+	if got := config.Meta.Meta(f); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+
+	if _, err := ParseConfig("front-matter author owner-name"); err == nil {
+		t.Errorf("expected error for unknown field")
+	}
+}
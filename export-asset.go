@@ -0,0 +1,93 @@
+package googledrive2hugo
+
+import (
+	"fmt"
+	"regexp"
+
+	"google.golang.org/api/drive/v3"
+)
+
+const (
+	mimeDrawing      = "application/vnd.google-apps.drawing"
+	mimePresentation = "application/vnd.google-apps.presentation"
+)
+
+func IsDrawing(f *drive.File) bool {
+	return f.MimeType == mimeDrawing
+}
+
+func IsPresentation(f *drive.File) bool {
+	return f.MimeType == mimePresentation
+}
+
+// assetFormats are the formats a Drawing or Slides deck can be
+// exported as, by file extension
+var assetFormats = map[string]map[string]string{
+	mimeDrawing: {
+		"svg": "image/svg+xml",
+		"png": "image/png",
+		"pdf": "application/pdf",
+	},
+	mimePresentation: {
+		"pdf": "application/pdf",
+		"png": "image/png",
+	},
+}
+
+// IsDrawingFormat reports whether ExportAsset can export a Drawing in
+// format
+func IsDrawingFormat(format string) bool {
+	_, ok := assetFormats[mimeDrawing][format]
+	return ok
+}
+
+// SlidesPNGs is the format for a PNG of every slide of a deck, see
+// ExportSlides
+const SlidesPNGs = "pngs"
+
+// IsSlidesFormat reports whether a Slides deck can be exported in
+// format, by ExportAsset or as SlidesPNGs by ExportSlides
+func IsSlidesFormat(format string) bool {
+	_, ok := assetFormats[mimePresentation][format]
+	return ok || format == SlidesPNGs
+}
+
+// ExportAsset downloads a Google Drawing or Slides deck as a static
+// file, in format "svg", "png" or "pdf".  Drive exports only the first
+// slide of a deck as "png", so that is best kept for single slide
+// decks.  Use ExportSlides for the others.
+func ExportAsset(src DriveSource, f *drive.File, format string) ([]byte, error) {
+	mimeType, ok := assetFormats[f.MimeType][format]
+	if !ok {
+		return nil, fmt.Errorf("%s: unable to export %s as %q", f.Name, f.MimeType, format)
+	}
+	return src.Export(f, mimeType)
+}
+
+// SlideExporter is a DriveSource that can export every slide of a
+// deck as an image, such as DriveService
+type SlideExporter interface {
+	ExportSlides(f *drive.File) ([][]byte, error)
+}
+
+// ExportSlides downloads every slide of a Slides deck as a PNG, in
+// order.  The source must be a SlideExporter.
+func ExportSlides(src DriveSource, f *drive.File) ([][]byte, error) {
+	exporter, ok := src.(SlideExporter)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support exporting slides", src)
+	}
+	return exporter.ExportSlides(f)
+}
+
+var reSlideImage = regexp.MustCompile(`^slide-\d+\.png$`)
+
+// SlideImage is the file name of slide n of a deck, starting with 1
+func SlideImage(n int) string {
+	return fmt.Sprintf("slide-%d.png", n)
+}
+
+// IsSlideImage is true for file names made by SlideImage
+func IsSlideImage(name string) bool {
+	return reSlideImage.MatchString(name)
+}
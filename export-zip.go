@@ -0,0 +1,85 @@
+package googledrive2hugo
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// ExportZip downloads a Google Doc as zipped HTML, which unlike
+// ExportHTML includes the images.  It returns the HTML and the images,
+// keyed by their name in the zip file ("images/image1.png"), which is
+// also how the HTML refers to them.
+func ExportZip(src DriveSource, f *drive.File) ([]byte, map[string][]byte, error) {
+	raw, err := src.Export(f, "application/zip")
+	if err != nil {
+		return nil, nil, err
+	}
+	return unzipHTML(raw)
+}
+
+// unzipHTML unpacks a zipped HTML export in memory
+func unzipHTML(raw []byte) ([]byte, map[string][]byte, error) {
+	r, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return nil, nil, err
+	}
+	var markup []byte
+	assets := make(map[string][]byte)
+	for _, zf := range r.File {
+		name := zf.Name
+		isHTML := path.Dir(name) == "." && path.Ext(name) == ".html"
+		if !isHTML && !strings.HasPrefix(name, "images/") {
+			continue
+		}
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if isHTML {
+			if markup != nil {
+				return nil, nil, fmt.Errorf("more than one HTML file in zip")
+			}
+			markup = content
+			continue
+		}
+		assets[name] = content
+	}
+	if markup == nil {
+		return nil, nil, fmt.Errorf("no HTML file found in zip")
+	}
+	return markup, assets, nil
+}
+
+// AssetFetcher resolves <img src> against the images from ExportZip.
+// Images not found are fetched with fallback, which may be nil.
+func AssetFetcher(assets map[string][]byte, fallback ImageFetcher) ImageFetcher {
+	return func(src string) ([]byte, error) {
+		if raw, ok := assets[src]; ok {
+			return raw, nil
+		}
+		if name, err := url.PathUnescape(src); err == nil {
+			if raw, ok := assets[name]; ok {
+				return raw, nil
+			}
+		}
+		if fallback == nil {
+			return nil, fmt.Errorf("image %q not found", src)
+		}
+		return fallback(src)
+	}
+}
@@ -0,0 +1,80 @@
+package googledrive2hugo
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/client9/ilog"
+)
+
+func TestExportZip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	png := "\x89PNG\r\n\x1a\nfake image"
+	doc := `<html><body><p><span><img src="images/image1.png"></span></p></body></html>`
+
+	// same layout as "Download as Web page (.html, zipped)"
+	f, err := os.Create(filepath.Join(dir, "post.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"Post.html":         doc,
+		"images/image1.png": png,
+	} {
+		zf, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zf.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	src := NewLocalSource(dir)
+	info, err := src.Get("post.zip")
+	if err != nil {
+		t.Fatalf("unable to get doc: %s", err)
+	}
+	markup, assets, err := ExportZip(src, info)
+	if err != nil {
+		t.Fatalf("unable to export: %s", err)
+	}
+	if string(markup) != doc {
+		t.Errorf("got markup %q", markup)
+	}
+	if string(assets["images/image1.png"]) != png {
+		t.Errorf("missing image in %v", assets)
+	}
+
+	c := Converter{
+		Logger: &ilog.NopLogger{},
+	}
+	out, images, err := c.ToBundle(markup, FileInfoToMeta(info), AssetFetcher(assets, nil))
+	if err != nil {
+		t.Fatalf("unable to convert: %s", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("expected one image, got %d", len(images))
+	}
+	for name := range images {
+		if !strings.Contains(string(out), `src="`+name+`"`) {
+			t.Errorf("src not rewritten to %q in %s", name, out)
+		}
+	}
+
+	if _, err := AssetFetcher(assets, nil)("images/missing.png"); err == nil {
+		t.Errorf("expected error for missing image")
+	}
+}
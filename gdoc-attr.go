@@ -1,13 +1,16 @@
 package googledrive2hugo
 
 import (
+	"strings"
+
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
 // GDocAttr remove all unnessecary attributes from a GDoc HTML tree
 // in particular removes all attributes except
 //
-//  * id
+//  * id of headings and bookmarks
 //  * href
 //  * colspan,rowspan if not "1"
 //
@@ -53,6 +56,12 @@ func removeAttr(n *html.Node) {
 			if len(n.Data) == 2 && n.Data[0] == 'h' {
 				n.Attr[idx] = n.Attr[i]
 				idx++
+				continue
+			}
+			// and bookmarks, which gdoc exports as <a id="id.xxx">
+			if n.DataAtom == atom.A && strings.HasPrefix(n.Attr[i].Val, "id.") {
+				n.Attr[idx] = n.Attr[i]
+				idx++
 			}
 		default:
 			continue
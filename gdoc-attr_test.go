@@ -0,0 +1,35 @@
+package googledrive2hugo
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestGdocAttr(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{`<h2 id="h.abc" class="c1">Heading</h2>`, `<h2 id="h.abc">Heading</h2>`},
+		{`<p id="p1" class="c2"><a id="id.xyz"></a>Bookmark</p>`, `<p><a id="id.xyz"></a>Bookmark</p>`},
+		{`<p><a href="#id.xyz" id="cmnt1">link</a></p>`, `<p><a href="#id.xyz">link</a></p>`},
+		{`<table><tr><td colspan="1" rowspan="2">x</td></tr></table>`, `<table><tbody><tr><td rowspan="2">x</td></tr></tbody></table>`},
+	}
+	body := newElementNode("body")
+	for _, tt := range cases {
+		nodes, err := html.ParseFragment(strings.NewReader(tt.in), body)
+		if err != nil {
+			t.Fatalf("unable to parse %q", tt.in)
+		}
+		var buf strings.Builder
+		for _, n := range nodes {
+			GdocAttr(n)
+			html.Render(&buf, n)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%q: want %q got %q", tt.in, tt.want, buf.String())
+		}
+	}
+}
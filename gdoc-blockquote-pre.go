@@ -7,6 +7,8 @@ package googledrive2hugo
 // but it's not code.
 
 import (
+	"fmt"
+
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 
@@ -19,9 +21,25 @@ var (
 )
 
 func GdocBlockquotePre(root *html.Node) error {
+	return gdocBlockquotePre(root, selectorBlockCode)
+}
+
+// GdocBlockquotePreIndent returns GdocBlockquotePre for paragraphs with
+// another indent, such as "72pt"
+func GdocBlockquotePreIndent(indent string) (func(*html.Node) error, error) {
+	if !reIndent.MatchString(indent) {
+		return nil, fmt.Errorf("indent %q is not in points, such as 36pt", indent)
+	}
+	sel := cascadia.MustCompile(`p[style="margin-left:` + indent + `"]>code:only-child`)
+	return func(root *html.Node) error {
+		return gdocBlockquotePre(root, sel)
+	}, nil
+}
+
+func gdocBlockquotePre(root *html.Node, sel cascadia.Selector) error {
 	var first *html.Node
 
-	for _, code := range selectorBlockCode.MatchAll(root) {
+	for _, code := range sel.MatchAll(root) {
 		// gdoc is <p><code>.. nothing between the <p> and <code>
 		// selector will match <p>foo<code> since it doesn't care about
 		// text nodes.  Make sure <code> is truly only child
@@ -1,6 +1,9 @@
 package googledrive2hugo
 
 import (
+	"fmt"
+	"regexp"
+
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 
@@ -9,13 +12,32 @@ import (
 
 var (
 	selectorBlockquote = cascadia.MustCompile(`p[style*="margin-left:36pt"]`)
+
+	// an indent in points, as gdoc writes them
+	reIndent = regexp.MustCompile(`^\d+(\.\d+)?pt$`)
 )
 
 // GdocBlockquote converts a sequence of <p style="margin-left:36pt"> to a blockquote
 //
 func GdocBlockquote(root *html.Node) error {
+	return gdocBlockquote(root, selectorBlockquote)
+}
+
+// GdocBlockquoteIndent returns GdocBlockquote for paragraphs with
+// another indent, such as "72pt"
+func GdocBlockquoteIndent(indent string) (func(*html.Node) error, error) {
+	if !reIndent.MatchString(indent) {
+		return nil, fmt.Errorf("indent %q is not in points, such as 36pt", indent)
+	}
+	sel := cascadia.MustCompile(`p[style*="margin-left:` + indent + `"]`)
+	return func(root *html.Node) error {
+		return gdocBlockquote(root, sel)
+	}, nil
+}
+
+func gdocBlockquote(root *html.Node, sel cascadia.Selector) error {
 	var first *html.Node
-	nodes := selectorBlockquote.MatchAll(root)
+	nodes := sel.MatchAll(root)
 	for _, n := range nodes {
 		// merge into previous
 		if first != nil && n.PrevSibling == first {
@@ -0,0 +1,124 @@
+package googledrive2hugo
+
+import (
+	"fmt"
+
+	"github.com/client9/ilog"
+	"golang.org/x/net/html"
+)
+
+// GdocTransform is one of the built-in passes that clean up the HTML
+// of a Google Doc, as a Runner.  A config that lists none of them runs
+// DefaultGdocTransforms before its filters.  Listing them by name runs
+// only those, in the order listed along with the other filters:
+//
+//	gdoc-img
+//	gdoc-span
+//	add-class h1 title
+//	gdoc-blockquote
+//
+// gdoc-blockquote and gdoc-blockquote-pre take the indent of the
+// paragraphs to turn into blockquotes, 36pt by default.  Giving it
+// tunes the pass wherever it runs, without listing it:
+//
+//	gdoc-blockquote 72pt
+//
+// "disable gdoc-blockquote" turns one off.
+type GdocTransform struct {
+	Name string
+
+	// Disabled turns the pass off, where it would otherwise run
+	Disabled bool
+
+	fn func(*html.Node) error
+}
+
+func (t *GdocTransform) Run(root *html.Node, log ilog.Logger) error {
+	if t.Disabled {
+		return nil
+	}
+	return t.fn(root)
+}
+
+// hasGdocTransform is true if runners include any built-in pass, even
+// a disabled one
+func hasGdocTransform(runners []Runner) bool {
+	for _, r := range runners {
+		if _, ok := r.(*GdocTransform); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var gdocTransforms = map[string]func(*html.Node) error{
+	"gdoc-img":            GdocImg,
+	"gdoc-span":           GdocSpan,
+	"gdoc-blockquote-pre": GdocBlockquotePre,
+	"gdoc-blockquote":     GdocBlockquote,
+	"gdoc-codeblock":      GdocCodeBlock,
+	"gdoc-table":          GdocTable,
+	"gdoc-attr":           GdocAttr,
+}
+
+// DefaultGdocTransforms are the names of the built-in passes run on
+// every doc, in order, unless configured otherwise
+var DefaultGdocTransforms = []string{
+	"gdoc-img",
+	"gdoc-span",
+	"gdoc-blockquote-pre",
+	"gdoc-blockquote",
+	"gdoc-codeblock",
+	"gdoc-table",
+	"gdoc-attr",
+}
+
+// gdocIndents are the built-in passes that take an indent
+var gdocIndents = map[string]func(string) (func(*html.Node) error, error){
+	"gdoc-blockquote-pre": GdocBlockquotePreIndent,
+	"gdoc-blockquote":     GdocBlockquoteIndent,
+}
+
+func IsGdocTransform(name string) bool {
+	_, ok := gdocTransforms[name]
+	return ok
+}
+
+// NewGdocTransform returns the built-in pass called name, with its
+// argument if it takes one
+func NewGdocTransform(name string, args ...string) (*GdocTransform, error) {
+	fn, ok := gdocTransforms[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown transform %q", name)
+	}
+	switch indent, takesIndent := gdocIndents[name]; {
+	case len(args) == 0:
+	case len(args) == 1 && takesIndent:
+		var err error
+		if fn, err = indent(args[0]); err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+	case takesIndent:
+		return nil, fmt.Errorf("%s: expected an indent, such as 36pt", name)
+	default:
+		return nil, fmt.Errorf("%s: takes no arguments", name)
+	}
+	return &GdocTransform{Name: name, fn: fn}, nil
+}
+
+// DefaultTransforms returns DefaultGdocTransforms as Runners
+func DefaultTransforms() []Runner {
+	out := make([]Runner, 0, len(DefaultGdocTransforms))
+	for _, name := range DefaultGdocTransforms {
+		t, err := NewGdocTransform(name)
+		if err != nil {
+			panic(err)
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func configGdocTransform(args []string) (Runner, error) {
+	return NewGdocTransform(args[0], args[1:]...)
+}
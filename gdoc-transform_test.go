@@ -0,0 +1,128 @@
+package googledrive2hugo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/client9/ilog"
+)
+
+// filterNames names the built-in passes that are on, and the type of
+// the other filters
+func filterNames(runners []Runner) []string {
+	names := []string{}
+	for _, r := range runners {
+		if t, ok := r.(*GdocTransform); ok {
+			if !t.Disabled {
+				names = append(names, t.Name)
+			}
+			continue
+		}
+		names = append(names, fmt.Sprintf("%T", r))
+	}
+	return names
+}
+
+func TestConfigGdocTransforms(t *testing.T) {
+	punc := "*googledrive2hugo.Punc"
+	tests := []struct {
+		config string
+		want   []string
+	}{
+		{"check-punc\n", append(append([]string{}, DefaultGdocTransforms...), punc)},
+		{"disable gdoc-blockquote\ndisable gdoc-blockquote-pre\n", []string{"gdoc-img", "gdoc-span", "gdoc-codeblock", "gdoc-table", "gdoc-attr"}},
+		{"gdoc-span\ncheck-punc\ngdoc-img\n", []string{"gdoc-span", punc, "gdoc-img"}},
+		{"gdoc-span\ndisable gdoc-span\n", []string{}},
+		{"gdoc-blockquote 72pt\n", DefaultGdocTransforms},
+		{"gdoc-span\ngdoc-blockquote\ngdoc-blockquote 72pt\n", []string{"gdoc-span", "gdoc-blockquote"}},
+	}
+	for _, tt := range tests {
+		config, err := ParseConfig(tt.config)
+		if err != nil {
+			t.Errorf("%q: %s", tt.config, err)
+			continue
+		}
+		if got := filterNames(config.Filters); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v want %v", tt.config, got, tt.want)
+		}
+	}
+	bad := []string{
+		"disable add-class\n",
+		"gdoc-img x\n",
+		"gdoc-blockquote 1in\n",
+		"gdoc-blockquote 36pt x\n",
+		"gdoc-span\ngdoc-blockquote 72pt\n",
+	}
+	for _, config := range bad {
+		if _, err := ParseConfig(config); err == nil {
+			t.Errorf("%q: expected error", config)
+		}
+	}
+
+	// Parse leaves the built-in passes to the Converter
+	filters, err := Parse("gdoc-span\ncheck-punc\n")
+	if err != nil {
+		t.Fatalf("unable to parse: %s", err)
+	}
+	if got := filterNames(filters); !reflect.DeepEqual(got, []string{punc}) {
+		t.Errorf("Parse: got %v", got)
+	}
+}
+
+func TestConverterTransforms(t *testing.T) {
+	doc := `<p style="margin-left:36pt">quote</p>`
+	c := Converter{Logger: &ilog.NopLogger{}}
+	out, err := c.parseFragment(doc)
+	if err != nil {
+		t.Fatalf("unable to convert: %s", err)
+	}
+	if !strings.Contains(out, "<blockquote>") {
+		t.Errorf("expected blockquote by default, got %s", out)
+	}
+
+	config, err := ParseConfig("disable gdoc-blockquote\n")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err)
+	}
+	c.Filters = config.Filters
+	out, err = c.parseFragment(doc)
+	if err != nil {
+		t.Fatalf("unable to convert: %s", err)
+	}
+	if strings.Contains(out, "<blockquote>") {
+		t.Errorf("expected no blockquote, got %s", out)
+	}
+
+	// a deeper indent is a blockquote, and the default one is not.
+	// The other default passes still run.
+	config, err = ParseConfig("gdoc-blockquote 72pt\n")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err)
+	}
+	c.Filters = config.Filters
+	out, err = c.parseFragment(doc + `<p style="margin-left:72pt">deeper</p><p><span class="c1">plain</span></p>`)
+	if err != nil {
+		t.Fatalf("unable to convert: %s", err)
+	}
+	if strings.Count(out, "<blockquote>") != 1 || !strings.Contains(out, "<blockquote>deeper") {
+		t.Errorf("expected only the 72pt blockquote, got %s", out)
+	}
+	if strings.Contains(out, "class=") {
+		t.Errorf("expected gdoc-attr to run, got %s", out)
+	}
+
+	// filters from Parse run after the default passes, once
+	filters, err := Parse("check-punc\n")
+	if err != nil {
+		t.Fatalf("unable to parse: %s", err)
+	}
+	c.Filters = filters
+	if out, err = c.parseFragment(doc); err != nil {
+		t.Fatalf("unable to convert: %s", err)
+	}
+	if strings.Count(out, "<blockquote>") != 1 {
+		t.Errorf("expected a blockquote, got %s", out)
+	}
+}
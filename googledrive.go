@@ -2,24 +2,172 @@ package googledrive2hugo
 
 import (
 	"fmt"
-	"io/ioutil"
 	"path/filepath"
 	"strings"
 
 	"google.golang.org/api/drive/v3"
 )
 
+const (
+	mimeFolder   = "application/vnd.google-apps.folder"
+	mimeDocument = "application/vnd.google-apps.document"
+	mimeShortcut = "application/vnd.google-apps.shortcut"
+)
+
 func IsDir(f *drive.File) bool {
-	return f.MimeType == "application/vnd.google-apps.folder"
+	return f.MimeType == mimeFolder
 }
 
 func IsGoogleDoc(f *drive.File) bool {
-	return f.MimeType == "application/vnd.google-apps.document"
+	return f.MimeType == mimeDocument
+}
+
+func IsShortcut(f *drive.File) bool {
+	return f.MimeType == mimeShortcut
 }
 
-type WalkFunc func(srv *drive.Service, path string, info *drive.File, err error) error
+// SectionIndex is the name of the optional Google Doc in a folder
+// that is the body of the folder's Hugo section page
+const SectionIndex = "_index"
+
+func IsSectionIndex(f *drive.File) bool {
+	return IsGoogleDoc(f) && f.Name == SectionIndex
+}
+
+// SectionMeta is the front matter for the Hugo section page
+// (_index.html) of a folder: the folder name is the title, and the
+// Drive description of the folder the description.
+func SectionMeta(folder *drive.File) map[string]interface{} {
+	meta := map[string]interface{}{
+		"title": folder.Name,
+	}
+	if folder.Description != "" {
+		meta["description"] = folder.Description
+	}
+	return meta
+}
+
+// TargetID is the ID of the file to read.  Walk passes files found
+// through a shortcut with the ID of the shortcut, and the ID of the
+// file itself in ShortcutDetails.
+func TargetID(f *drive.File) string {
+	if f.ShortcutDetails != nil && f.ShortcutDetails.TargetId != "" {
+		return f.ShortcutDetails.TargetId
+	}
+	return f.Id
+}
+
+// followShortcut returns the target of a shortcut as seen from the
+// shortcut: it has the shortcut's ID and name, but otherwise is the
+// target.
+func followShortcut(src DriveSource, shortcut *drive.File) (*drive.File, error) {
+	if shortcut.ShortcutDetails == nil || shortcut.ShortcutDetails.TargetId == "" {
+		return nil, fmt.Errorf("shortcut %q has no target", shortcut.Name)
+	}
+	target, err := src.Get(shortcut.ShortcutDetails.TargetId)
+	if err != nil {
+		return nil, err
+	}
+	out := *target
+	out.Id = shortcut.Id
+	out.Name = shortcut.Name
+	out.ShortcutDetails = &drive.FileShortcutDetails{
+		TargetId:       target.Id,
+		TargetMimeType: target.MimeType,
+	}
+	return &out, nil
+}
+
+// DriveSource is what is needed from Google Drive to walk a tree of
+// folders and export the documents in it.
+//
+// DriveService talks to Google Drive, LocalSource reads a directory
+// of already exported HTML.
+type DriveSource interface {
+	// List returns the children of a folder
+	List(folder *drive.File) ([]*drive.File, error)
+
+	// Resolve returns the folder found at a slash separated path
+	Resolve(path string) (*drive.File, error)
+
+	// Get returns a file by ID
+	Get(id string) (*drive.File, error)
+
+	// Export returns the contents of a file converted to mimeType
+	Export(f *drive.File, mimeType string) ([]byte, error)
+}
+
+// Searcher is a DriveSource that can find files using a Drive query,
+// such as DriveService
+type Searcher interface {
+	Search(query string) ([]*drive.File, error)
+}
+
+// Pager is a DriveSource that can list a folder or search one page
+// at a time, so ThrottledSource can pace and retry each page, such as
+// DriveService.  The next page token is empty after the last page.
+type Pager interface {
+	ListPage(folder *drive.File, pageToken string) ([]*drive.File, string, error)
+	SearchPage(query string, pageToken string) ([]*drive.File, string, error)
+}
+
+// Changer is a DriveSource with a feed of changed files, such as
+// DriveService
+type Changer interface {
+	// StartPageToken returns the token for changes made from now on
+	StartPageToken() (string, error)
+
+	// Changes returns the changes since token, and the token for
+	// the changes after them
+	Changes(token string) ([]*drive.Change, string, error)
+}
+
+// EscapeQuery escapes a string for use inside single quotes in a
+// Drive query
+func EscapeQuery(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return strings.Replace(s, "'", `\'`, -1)
+}
+
+// SplitPath splits a slash separated path into folder names.  A "/"
+// that is part of a name is escaped as "\/", and a "\" as "\\".
+func SplitPath(path string) []string {
+	var parts []string
+	var name []rune
+	escaped := false
+	for _, r := range path {
+		switch {
+		case escaped:
+			name = append(name, r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '/':
+			parts = append(parts, string(name))
+			name = name[:0]
+		default:
+			name = append(name, r)
+		}
+	}
+	return append(parts, string(name))
+}
+
+type WalkFunc func(src DriveSource, path string, info *drive.File, err error) error
+
+// WalkStats is the number of children Walk enumerated in each folder,
+// keyed by folder path.  The root folder is "".
+type WalkStats map[string]int
 
-func walk(srv *drive.Service, path string, info *drive.File, walkFn WalkFunc) error {
+// Total returns the number of children enumerated in all folders
+func (s WalkStats) Total() int {
+	total := 0
+	for _, n := range s {
+		total += n
+	}
+	return total
+}
+
+func walk(src DriveSource, path string, info *drive.File, walkFn WalkFunc, stats WalkStats, ancestors map[string]bool) error {
 	// search returns trashed files...
 	// skip them
 	// TODO: can search just skip these automatically?
@@ -27,7 +175,13 @@ func walk(srv *drive.Service, path string, info *drive.File, walkFn WalkFunc) er
 		return nil
 	}
 
-	err := walkFn(srv, path, info, nil)
+	// a shortcut to a folder above this one would loop forever
+	folderID := TargetID(info)
+	if IsDir(info) && ancestors[folderID] {
+		return walkFn(src, path, info, fmt.Errorf("shortcut %q loops to a parent folder", info.Name))
+	}
+
+	err := walkFn(src, path, info, nil)
 	if err != nil {
 		if IsDir(info) && err != filepath.SkipDir {
 			return nil
@@ -39,83 +193,127 @@ func walk(srv *drive.Service, path string, info *drive.File, walkFn WalkFunc) er
 		return nil
 	}
 
-	query := fmt.Sprintf("parents in '%s'", info.Id)
-	names, err := srv.Files.List().Fields("files(id,name,mimeType,createdTime,modifiedTime,description)").Spaces("drive").Corpora("user").Q(query).Do()
-
+	files, err := src.List(info)
 	if err != nil {
-		return walkFn(srv, path, info, err)
+		return walkFn(src, path, info, err)
 	}
+	stats[path] = len(files)
 
-	for _, fileInfo := range names.Files {
+	ancestors[folderID] = true
+	defer delete(ancestors, folderID)
+
+	for _, fileInfo := range files {
 		// skip trashed files
 		if fileInfo.Trashed {
 			continue
 		}
 		filename := filepath.Join(path, fileInfo.Name)
-		if err != nil {
-			if err := walkFn(srv, filename, fileInfo, err); err != nil && err != filepath.SkipDir {
-				return err
-			}
-		} else {
-			err = walk(srv, filename, fileInfo, walkFn)
+		if IsShortcut(fileInfo) {
+			target, err := followShortcut(src, fileInfo)
 			if err != nil {
-				if !IsDir(fileInfo) || err != filepath.SkipDir {
+				if err := walkFn(src, filename, fileInfo, err); err != nil && err != filepath.SkipDir {
 					return err
 				}
+				continue
+			}
+			fileInfo = target
+		}
+		err = walk(src, filename, fileInfo, walkFn, stats, ancestors)
+		if err != nil {
+			if !IsDir(fileInfo) || err != filepath.SkipDir {
+				return err
 			}
 		}
 	}
 	return nil
 }
 
-func Walk(srv *drive.Service, root string, walkfn WalkFunc) error {
-	var info *drive.File
-	parent := "root"
+// Walk walks the folder tree found at root, calling walkfn for
+// each file or folder, in the style of filepath.Walk.  It returns the
+// number of children found in each folder, even if the walk failed.
+//
+// Shortcuts are followed.  The target is visited under the path of
+// the shortcut, with the shortcut's ID and name.  A shortcut to a
+// parent folder is passed to walkfn as an error.
+func Walk(src DriveSource, root string, walkfn WalkFunc) (WalkStats, error) {
+	stats := make(WalkStats)
+	info, err := src.Resolve(root)
+	if err != nil {
+		return stats, err
+	}
+	return walkRoot(src, info, walkfn, stats)
+}
 
-	// TODO -- this may not be exactly right
-	//  but can't find the right thing in golang path module
-	parts := strings.Split(root, "/")
+// WalkID is like Walk, but starts from a folder ID rather than a path
+func WalkID(src DriveSource, id string, walkfn WalkFunc) (WalkStats, error) {
+	stats := make(WalkStats)
+	info, err := src.Get(id)
+	if err != nil {
+		return stats, err
+	}
+	return walkRoot(src, info, walkfn, stats)
+}
 
-	for _, dir := range parts {
-		query := fmt.Sprintf("name='%s' and mimeType='application/vnd.google-apps.folder' and '%s' in parents", dir, parent)
-		r, err := srv.Files.List().Spaces("drive").Corpora("user").Q(query).Do()
-		if err != nil {
-			return err
+// WalkQuery walks every file matching a Drive query, for example
+// "properties has { key='publish' and value='true' }".  Files in the
+// trash are left out.  Each match has a path of its name, or if more
+// than one match has the same name, of its name and ID, as in
+// "notes-1a2b3c".  Folders are walked as in Walk.  The source must be
+// a Searcher.
+func WalkQuery(src DriveSource, query string, walkfn WalkFunc) (WalkStats, error) {
+	stats := make(WalkStats)
+	searcher, ok := src.(Searcher)
+	if !ok {
+		return stats, fmt.Errorf("%T does not support queries", src)
+	}
+	files, err := searcher.Search("(" + query + ") and trashed = false")
+	if err != nil {
+		return stats, err
+	}
+	stats[""] = len(files)
+	names := make(map[string]int)
+	for _, info := range files {
+		names[info.Name]++
+	}
+	for _, info := range files {
+		path := info.Name
+		if names[info.Name] > 1 {
+			path += "-" + info.Id
 		}
-		if len(r.Files) == 0 {
-			return fmt.Errorf("%q not found", root)
+		if IsShortcut(info) {
+			target, err := followShortcut(src, info)
+			if err != nil {
+				if err := walkfn(src, path, info, err); err != nil && err != filepath.SkipDir {
+					return stats, err
+				}
+				continue
+			}
+			info = target
 		}
-		if len(r.Files) > 1 {
-			return fmt.Errorf("more than file for %q found", root)
+		err = walk(src, path, info, walkfn, stats, make(map[string]bool))
+		if err != nil && err != filepath.SkipDir {
+			return stats, err
 		}
-		info = r.Files[0]
-		parent = info.Id
 	}
-	err := walk(srv, "", info, walkfn)
+	return stats, nil
+}
+
+func walkRoot(src DriveSource, info *drive.File, walkfn WalkFunc, stats WalkStats) (WalkStats, error) {
+	err := walk(src, "", info, walkfn, stats, make(map[string]bool))
 	if err == filepath.SkipDir {
-		return nil
+		return stats, nil
 	}
-	return err
+	return stats, err
 }
 
-// ExportHTML downloads a Google Doc as HTML
-//  Download as Zip and unzip
-func ExportHTML(srv *drive.Service, f *drive.File) ([]byte, error) {
-	resp, err := srv.Files.Export(f.Id, "text/html").Download()
-	if err != nil {
-		return nil, err
-	}
-	out, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	return out, resp.Body.Close()
+// ExportHTML downloads a Google Doc as HTML.  Images are links to
+// googleusercontent.com, use ExportZip to get the images too.
+func ExportHTML(src DriveSource, f *drive.File) ([]byte, error) {
+	return src.Export(f, "text/html")
 }
 
 // output map is the one used by Hugo
 func FileInfoToMeta(fileInfo *drive.File) map[string]interface{} {
-	meta := make(map[string]interface{})
-
 	// Google File Metadata:
 	// https://godoc.org/google.golang.org/api/drive/v3#File
 	//
@@ -124,11 +322,5 @@ func FileInfoToMeta(fileInfo *drive.File) map[string]interface{} {
 	// Hugo Front Matter:
 	// https://gohugo.io/content-management/front-matter/
 	//
-	meta["date"] = fileInfo.CreatedTime
-	meta["lastmod"] = fileInfo.ModifiedTime
-	if fileInfo.Description != "" {
-		meta["description"] = fileInfo.Description
-	}
-
-	return meta
+	return DefaultMetaMap.Meta(fileInfo)
 }
@@ -0,0 +1,140 @@
+package googledrive2hugo
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+)
+
+func TestEscapeQuery(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"Bob's Folder", `Bob\'s Folder`},
+		{`back\slash`, `back\\slash`},
+	}
+	for _, tt := range cases {
+		if got := EscapeQuery(tt.in); got != tt.want {
+			t.Errorf("%q: want %q got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{"a", []string{"a"}},
+		{"a/b", []string{"a", "b"}},
+		{`Q1\/Q2/notes`, []string{"Q1/Q2", "notes"}},
+		{`a\\/b`, []string{`a\`, "b"}},
+	}
+	for _, tt := range cases {
+		if got := SplitPath(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: want %q got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+// searchSource answers every query with the same files
+type searchSource struct {
+	DriveSource
+	files []*drive.File
+	query string
+}
+
+func (s *searchSource) Search(query string) ([]*drive.File, error) {
+	s.query = query
+	return s.files, nil
+}
+
+func TestWalkIDAndQuery(t *testing.T) {
+	local := NewLocalSource("testdata/drive")
+	var got []string
+	fn := func(src DriveSource, path string, info *drive.File, err error) error {
+		if err != nil {
+			t.Fatalf("walk error on %q: %s", path, err)
+		}
+		got = append(got, path)
+		return nil
+	}
+
+	if _, err := WalkID(local, "posts", fn); err != nil {
+		t.Fatalf("walk failed: %s", err)
+	}
+	want := []string{"", "hello-world"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+
+	if _, err := WalkQuery(local, "name = 'about'", fn); err == nil {
+		t.Errorf("expected error walking a query without a Searcher")
+	}
+
+	about, _ := local.Get("about.html")
+	posts, _ := local.Get("posts")
+	src := &searchSource{
+		DriveSource: local,
+		files:       []*drive.File{about, posts},
+	}
+	got = nil
+	if _, err := WalkQuery(src, "name = 'about' or name = 'posts'", fn); err != nil {
+		t.Fatalf("walk failed: %s", err)
+	}
+	want = []string{"about", "posts", "posts/hello-world"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+	if want := "(name = 'about' or name = 'posts') and trashed = false"; src.query != want {
+		t.Errorf("want query %q got %q", want, src.query)
+	}
+
+	// two docs with the same name don't write to the same path
+	other := *about
+	other.Id = "other"
+	src.files = []*drive.File{about, &other}
+	got = nil
+	if _, err := WalkQuery(src, "name = 'about'", fn); err != nil {
+		t.Fatalf("walk failed: %s", err)
+	}
+	want = []string{"about-" + about.Id, "about-other"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+}
+
+// a shortcut to a file that can't be read is passed to walkfn, and
+// the walk goes on
+func TestWalkQueryDanglingShortcut(t *testing.T) {
+	local := NewLocalSource("testdata/drive")
+	about, _ := local.Get("about.html")
+	dangling := &drive.File{
+		Id:              "dangling",
+		Name:            "gone",
+		MimeType:        mimeShortcut,
+		ShortcutDetails: &drive.FileShortcutDetails{TargetId: "missing"},
+	}
+	src := &searchSource{
+		DriveSource: local,
+		files:       []*drive.File{dangling, about},
+	}
+	var got, failed []string
+	_, err := WalkQuery(src, "name = 'about'", func(src DriveSource, path string, info *drive.File, err error) error {
+		if err != nil {
+			failed = append(failed, path)
+			return nil
+		}
+		got = append(got, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %s", err)
+	}
+	if !reflect.DeepEqual(failed, []string{"gone"}) || !reflect.DeepEqual(got, []string{"about"}) {
+		t.Errorf("got %v, failed %v", got, failed)
+	}
+}
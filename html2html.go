@@ -2,6 +2,9 @@ package googledrive2hugo
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 
 	"github.com/gohugoio/hugo/parser"
 	"github.com/gohugoio/hugo/parser/metadecoders"
@@ -18,14 +21,24 @@ func MetaMerge(a, b map[string]interface{}) map[string]interface{} {
 	return a
 }
 
+// IsFrontMatterFormat reports whether HugoContentWrite supports the
+// format
+func IsFrontMatterFormat(format string) bool {
+	switch format {
+	case "", "yaml", "toml", "json":
+		return true
+	}
+	return false
+}
+
 // HugoContent takes front-matter data, content and writes it
 // to output stream
 //
-func HugoContentWrite(content []byte, metamap map[string]interface{}) ([]byte, error) {
+// The front matter is "yaml" (or ""), "toml" or "json".  Keys are
+// sorted in every format, though TOML has to put tables last.
+func HugoContentWrite(content []byte, metamap map[string]interface{}, format string) ([]byte, error) {
 	w := &bytes.Buffer{}
-	// TODO: '-' produces yaml, '+' toml, '{' JSON
-	//  should make a flag
-	if err := parser.InterfaceToFrontMatter(metamap, metadecoders.YAML, w); err != nil {
+	if err := writeFrontMatter(w, metamap, format); err != nil {
 		return nil, err
 	}
 	if _, err := w.Write(content); err != nil {
@@ -33,3 +46,19 @@ func HugoContentWrite(content []byte, metamap map[string]interface{}) ([]byte, e
 	}
 	return w.Bytes(), nil
 }
+
+func writeFrontMatter(w io.Writer, metamap map[string]interface{}, format string) error {
+	switch format {
+	case "", "yaml":
+		return parser.InterfaceToFrontMatter(metamap, metadecoders.YAML, w)
+	case "toml":
+		return parser.InterfaceToFrontMatter(metamap, metadecoders.TOML, w)
+	case "json":
+		// not parser.InterfaceToFrontMatter, which escapes "&" and "<"
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(metamap)
+	}
+	return fmt.Errorf("unknown front matter format %q", format)
+}
@@ -0,0 +1,50 @@
+package googledrive2hugo
+
+import (
+	"testing"
+)
+
+func TestHugoContentWrite(t *testing.T) {
+	meta := map[string]interface{}{
+		"title":   "A & B",
+		"tags":    []string{"go", "hugo"},
+		"draft":   true,
+		"weight":  3,
+		"params":  map[string]interface{}{"x": "z"},
+		"aliases": []string{"/old"},
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"", "---\naliases:\n- /old\ndraft: true\nparams:\n  x: z\ntags:\n- go\n- hugo\ntitle: A & B\nweight: 3\n---\n<p>hi</p>"},
+		{"toml", "+++\naliases = ['/old']\ndraft = true\ntags = ['go', 'hugo']\ntitle = 'A & B'\nweight = 3\n\n[params]\n  x = 'z'\n+++\n<p>hi</p>"},
+		{"json", "{\n  \"aliases\": [\n    \"/old\"\n  ],\n  \"draft\": true,\n  \"params\": {\n    \"x\": \"z\"\n  },\n  \"tags\": [\n    \"go\",\n    \"hugo\"\n  ],\n  \"title\": \"A & B\",\n  \"weight\": 3\n}\n<p>hi</p>"},
+	}
+	for _, tt := range tests {
+		out, err := HugoContentWrite([]byte("<p>hi</p>"), meta, tt.format)
+		if err != nil {
+			t.Errorf("%q: %s", tt.format, err)
+			continue
+		}
+		if string(out) != tt.want {
+			t.Errorf("%q: got\n%s\nwant\n%s", tt.format, out, tt.want)
+		}
+	}
+	if _, err := HugoContentWrite(nil, meta, "xml"); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
+
+func TestConfigFrontMatterFormat(t *testing.T) {
+	config, err := ParseConfig("front-matter-format toml\n")
+	if err != nil {
+		t.Fatalf("unable to parse config: %s", err)
+	}
+	if config.FrontMatter != "toml" {
+		t.Errorf("got %q want toml", config.FrontMatter)
+	}
+	if _, err := ParseConfig("front-matter-format xml\n"); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
@@ -1,7 +1,12 @@
 package googledrive2hugo
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/andybalholm/cascadia"
@@ -13,8 +18,53 @@ import (
 var (
 	selectorTitle    = cascadia.MustCompile("p[class~=title]")
 	selectorSubtitle = cascadia.MustCompile("p[class~=subtitle]")
+
+	// goccy/go-yaml errors start with "[line:column] "
+	reYAMLErrorPos = regexp.MustCompile(`^\[(\d+):\d+\] `)
 )
 
+// frontMatterDelims maps the opening delimiter of a front matter block
+// to its closing delimiter and format, as in Hugo
+var frontMatterDelims = map[string]struct {
+	end    string
+	format metadecoders.Format
+}{
+	"---": {"---", metadecoders.YAML},
+	"+++": {"+++", metadecoders.TOML},
+	"{":   {"}", metadecoders.JSON},
+}
+
+// FrontMatterError is a front matter block in a doc that can't be
+// parsed
+type FrontMatterError struct {
+	// Doc is the name of the doc, set by the caller if known
+	Doc string
+
+	// Line is the line of the block with the error, counting the
+	// opening delimiter as line 1, or 0 if the decoder didn't say
+	Line int
+
+	// Text is the text of the line
+	Text string
+
+	Err error
+}
+
+func (e *FrontMatterError) Error() string {
+	msg := "front matter"
+	if e.Doc != "" {
+		msg = e.Doc + ": " + msg
+	}
+	if e.Line > 0 {
+		msg += fmt.Sprintf(" line %d %q", e.Line, e.Text)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *FrontMatterError) Unwrap() error {
+	return e.Err
+}
+
 func HugoFrontMatter(root *html.Node) (map[string]interface{}, error) {
 	var front string
 	var fStart *html.Node
@@ -32,7 +82,7 @@ func HugoFrontMatter(root *html.Node) (map[string]interface{}, error) {
 			if text == "" {
 				continue
 			}
-			if text == "---" || text == "{" || text == "+++" {
+			if _, ok := frontMatterDelims[text]; ok {
 				front = text + "\n"
 				fStart = c
 				break
@@ -55,19 +105,32 @@ func HugoFrontMatter(root *html.Node) (map[string]interface{}, error) {
 		return make(map[string]interface{}), nil
 	}
 
-	// find ending
+	// find ending, which must match the start.  JSON ends at the
+	// brace closing the opening one, not at any line with a "}".
+	delim := frontMatterDelims[strings.TrimSpace(front)]
+	depth := 1
 	for c := fStart.NextSibling; c != nil; c = c.NextSibling {
 		if c.DataAtom != atom.Hr && c.DataAtom != atom.P {
 			break
 		}
 		if c.DataAtom == atom.Hr {
+			if delim.end != "---" {
+				break
+			}
 			front += "---\n"
 			fEnd = c
 			break
 		}
 		text := getTextContent(c)
 		front += text + "\n"
-		if text == "---" || text == "}" || text == "+++" {
+		if delim.format == metadecoders.JSON {
+			if depth = jsonDepth(depth, unsmart(text)); depth <= 0 {
+				fEnd = c
+				break
+			}
+			continue
+		}
+		if strings.TrimSpace(text) == delim.end {
 			fEnd = c
 			break
 		}
@@ -94,9 +157,8 @@ func HugoFrontMatter(root *html.Node) (map[string]interface{}, error) {
 	// the front matter is code!
 	front = unsmart(front)
 
-	meta, err := metadecoders.Default.UnmarshalToMap([]byte(front), metadecoders.YAML)
+	meta, err := decodeFrontMatter(front, delim.format)
 	if err != nil {
-		log.Printf("FRONT:\n%s", front)
 		return nil, err
 	}
 	if title := extractTitle(root); title != "" {
@@ -113,6 +175,95 @@ func HugoFrontMatter(root *html.Node) (map[string]interface{}, error) {
 	return meta, nil
 }
 
+// jsonDepth adds the braces opened and closed on a line of JSON to
+// depth, skipping braces in strings
+func jsonDepth(depth int, line string) int {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// decodeFrontMatter decodes a front matter block, including its
+// delimiters.  Errors are a *FrontMatterError.
+func decodeFrontMatter(front string, format metadecoders.Format) (map[string]interface{}, error) {
+	lines := strings.Split(strings.TrimSuffix(front, "\n"), "\n")
+
+	// JSON keeps its braces, the others lose the delimiter lines
+	data, offset := front, 0
+	if format != metadecoders.JSON {
+		data = strings.Join(lines[1:len(lines)-1], "\n") + "\n"
+		offset = 1
+	}
+	meta, err := metadecoders.Default.UnmarshalToMap([]byte(data), format)
+	if err == nil {
+		return meta, nil
+	}
+	line, msg := decodeErrorLine(err, data)
+	ferr := &FrontMatterError{
+		Err: errors.New(msg),
+	}
+	if line > 0 && line+offset <= len(lines) {
+		ferr.Line = line + offset
+		ferr.Text = lines[ferr.Line-1]
+	}
+	return nil, ferr
+}
+
+// decodeErrorLine returns the line in data of an error from the
+// decoders, and the error message without the excerpt of data some
+// decoders add
+func decodeErrorLine(err error, data string) (int, string) {
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		return lineAt(data, syntax.Offset), syntax.Error()
+	}
+	var typ *json.UnmarshalTypeError
+	if errors.As(err, &typ) {
+		return lineAt(data, typ.Offset), typ.Error()
+	}
+
+	// go-toml
+	var pos interface {
+		error
+		Position() (row int, column int)
+	}
+	if errors.As(err, &pos) {
+		row, _ := pos.Position()
+		return row, pos.Error()
+	}
+
+	msg := strings.SplitN(err.Error(), "\n", 2)[0]
+	if m := reYAMLErrorPos.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return line, msg[len(m[0]):]
+	}
+	return 0, msg
+}
+
+// lineAt is the line of a byte offset in s, starting with 1.  JSON
+// offsets are just past the error.
+func lineAt(s string, offset int64) int {
+	if offset > 0 {
+		offset--
+	}
+	if offset > int64(len(s)) {
+		offset = int64(len(s))
+	}
+	return strings.Count(s[:offset], "\n") + 1
+}
+
 func extractTitle(root *html.Node) string {
 	n := selectorTitle.MatchFirst(root)
 	if n == nil {
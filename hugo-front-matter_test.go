@@ -0,0 +1,97 @@
+package googledrive2hugo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func frontMatterDoc(t *testing.T, lines ...string) *html.Node {
+	var src strings.Builder
+	for _, line := range lines {
+		src.WriteString("<p>" + html.EscapeString(line) + "</p>")
+	}
+	src.WriteString("<p>body</p>")
+	body := newElementNode("body")
+	nodes, err := html.ParseFragment(strings.NewReader(src.String()), body)
+	if err != nil {
+		t.Fatalf("unable to parse: %s", err)
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	return body
+}
+
+func TestHugoFrontMatterFormats(t *testing.T) {
+	want := map[string]interface{}{
+		"slug": "hello",
+		"tags": []interface{}{"a", "b"},
+	}
+	tests := [][]string{
+		{"---", "slug: hello", "tags: [a, b]", "---"},
+		{"+++", "slug = “hello”", "tags = ['a', 'b']", "+++"},
+		{"{", `"slug": "hello",`, `"tags": ["a", "b"]`, "}"},
+	}
+	for _, lines := range tests {
+		root := frontMatterDoc(t, lines...)
+		meta, err := HugoFrontMatter(root)
+		if err != nil {
+			t.Errorf("%s: %s", lines[0], err)
+			continue
+		}
+		if !reflect.DeepEqual(meta, want) {
+			t.Errorf("%s: got %v want %v", lines[0], meta, want)
+		}
+		if got := getTextContent(root); got != "body" {
+			t.Errorf("%s: front matter not removed, got %q", lines[0], got)
+		}
+	}
+}
+
+// the block ends at the brace closing the first one, not at an inner
+// one or a brace in a string
+func TestHugoFrontMatterNestedJSON(t *testing.T) {
+	root := frontMatterDoc(t, "{", `"slug": "a}b",`, `"params": {`, `  "x": “{z”`, "  }", "}")
+	meta, err := HugoFrontMatter(root)
+	if err != nil {
+		t.Fatalf("unable to parse: %s", err)
+	}
+	want := map[string]interface{}{
+		"slug":   "a}b",
+		"params": map[string]interface{}{"x": "{z"},
+	}
+	if !reflect.DeepEqual(meta, want) {
+		t.Errorf("got %v want %v", meta, want)
+	}
+	if got := getTextContent(root); got != "body" {
+		t.Errorf("front matter not removed, got %q", got)
+	}
+}
+
+func TestHugoFrontMatterError(t *testing.T) {
+	tests := []struct {
+		lines []string
+		line  int
+	}{
+		{[]string{"---", "slug: hello", "tags: [a, b", "draft: true", "---"}, 4},
+		{[]string{"+++", "slug = 'hello'", "tags = [a]", "+++"}, 3},
+		{[]string{"{", `"slug": "hello",`, `"tags": [a]`, "}"}, 3},
+	}
+	for _, tt := range tests {
+		_, err := HugoFrontMatter(frontMatterDoc(t, tt.lines...))
+		ferr, ok := err.(*FrontMatterError)
+		if !ok {
+			t.Errorf("%s: expected *FrontMatterError, got %v", tt.lines[0], err)
+			continue
+		}
+		if ferr.Line != tt.line || ferr.Text != tt.lines[tt.line-1] {
+			t.Errorf("%s: got line %d %q, want %d %q", tt.lines[0], ferr.Line, ferr.Text, tt.line, tt.lines[tt.line-1])
+		}
+		if strings.Contains(ferr.Error(), "\n") {
+			t.Errorf("%s: error has more than one line: %s", tt.lines[0], ferr)
+		}
+	}
+}
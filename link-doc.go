@@ -0,0 +1,105 @@
+package googledrive2hugo
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/client9/ilog"
+	"golang.org/x/net/html"
+)
+
+// matches the ID in https://docs.google.com/document/d/<id>/edit
+var docLinkPath = regexp.MustCompile(`^/document/d/([-\w]+)`)
+
+// DocLinkID returns the ID of the Google Doc a link points to and the
+// fragment to use on the Hugo page, or "" if it does not point to a
+// doc.  Links to a heading ("#heading=h.xxx") or bookmark
+// ("#bookmark=id.xxx") become the ID of the heading or bookmark, which
+// is kept in the exported HTML.
+func DocLinkID(link string) (string, string) {
+	u, err := url.Parse(link)
+	if err != nil || u.Host != "docs.google.com" {
+		return "", ""
+	}
+	m := docLinkPath.FindStringSubmatch(u.Path)
+	if m == nil {
+		return "", ""
+	}
+	fragment := u.Fragment
+	for _, prefix := range []string{"heading=", "bookmark="} {
+		if strings.HasPrefix(fragment, prefix) {
+			fragment = fragment[len(prefix):]
+		}
+	}
+	return m[1], fragment
+}
+
+// DocLinks rewrites links to other Google Docs in the same walk to
+// links to their Hugo pages.  Links to docs not in the walk are left
+// as is, and logged.
+type DocLinks struct {
+	// Paths maps Google Doc ID to the content file, relative to the
+	// Hugo content directory, such as "posts/hello-world.html" or
+	// "posts/hello-world/index.html"
+	Paths map[string]string
+
+	// Ref uses the {{< ref >}} shortcode instead of a URL, so Hugo
+	// checks the link and honors slugs and permalinks
+	Ref bool
+
+	selector cascadia.Selector
+}
+
+// NewDocLinks makes a DocLinks from a map of doc ID to content file
+func NewDocLinks(paths map[string]string, ref bool) *DocLinks {
+	return &DocLinks{
+		Paths:    paths,
+		Ref:      ref,
+		selector: cascadia.MustCompile("a[href]"),
+	}
+}
+
+func (n *DocLinks) Run(root *html.Node, log ilog.Logger) error {
+	for _, node := range n.selector.MatchAll(root) {
+		for i, attr := range node.Attr {
+			if attr.Key != "href" {
+				continue
+			}
+			id, fragment := DocLinkID(attr.Val)
+			if id == "" {
+				continue
+			}
+			file, ok := n.Paths[id]
+			if !ok {
+				log.Info("link to doc outside of walk", "url", attr.Val)
+				continue
+			}
+			link := n.link(file, fragment)
+			log.Debug("doc link", "url", attr.Val, "to", link)
+			node.Attr[i].Val = link
+		}
+	}
+	return nil
+}
+
+// link is the URL or ref shortcode for a content file
+func (n *DocLinks) link(file string, fragment string) string {
+	file = strings.TrimSuffix(file, path.Ext(file))
+	if path.Base(file) == "index" || path.Base(file) == "_index" {
+		file = path.Dir(file)
+	}
+	if fragment != "" {
+		fragment = "#" + fragment
+	}
+	if n.Ref {
+		return fmt.Sprintf("{{< ref %q >}}", file+fragment)
+	}
+	if file == "." {
+		return "/" + fragment
+	}
+	return "/" + file + "/" + fragment
+}
@@ -0,0 +1,48 @@
+package googledrive2hugo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/client9/ilog"
+	"golang.org/x/net/html"
+)
+
+func TestDocLinks(t *testing.T) {
+	paths := map[string]string{
+		"doc1": "posts/hello-world.html",
+		"doc2": "about/index.html",
+	}
+	cases := []struct {
+		href string
+		ref  bool
+		want string
+	}{
+		{"https://docs.google.com/document/d/doc1/edit", false, "/posts/hello-world/"},
+		{"https://docs.google.com/document/d/doc1/edit#heading=h.abc1", false, "/posts/hello-world/#h.abc1"},
+		{"https://docs.google.com/document/d/doc2/edit?usp=sharing", false, "/about/"},
+		{"https://docs.google.com/document/d/doc1/edit#heading=h.abc1", true, `{{< ref "posts/hello-world#h.abc1" >}}`},
+		{"https://docs.google.com/document/d/doc2/edit", true, `{{< ref "about" >}}`},
+		{"https://docs.google.com/document/d/elsewhere/edit", false, "https://docs.google.com/document/d/elsewhere/edit"},
+		{"https://www.example.com/document/d/doc1/edit", false, "https://www.example.com/document/d/doc1/edit"},
+	}
+	body := newElementNode("body")
+	for _, tt := range cases {
+		nodes, err := html.ParseFragment(strings.NewReader(`<a href="`+tt.href+`">link</a>`), body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := NewDocLinks(paths, tt.ref)
+		if err := n.Run(nodes[0], &ilog.NopLogger{}); err != nil {
+			t.Fatal(err)
+		}
+		buf := bytes.Buffer{}
+		html.Render(&buf, nodes[0])
+		got := string(unescapeShortcodes(buf.Bytes()))
+		want := `<a href="` + tt.want + `">link</a>`
+		if got != want {
+			t.Errorf("%s: want %s got %s", tt.href, want, got)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package googledrive2hugo
+
+import (
+	"net/url"
+	"regexp"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/client9/ilog"
+	"golang.org/x/net/html"
+)
+
+// matches the Drive file ID in links such as
+//
+//	https://docs.google.com/drawings/d/<id>/edit
+//	https://docs.google.com/presentation/d/<id>/edit#slide=id.p
+//	https://drive.google.com/file/d/<id>/view
+var driveLinkPath = regexp.MustCompile(`^/(?:drawings|presentation|file)/d/([-\w]+)`)
+
+// DriveFileID returns the ID of the Drive file a link points to, or ""
+// if it does not point to one
+func DriveFileID(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	switch u.Host {
+	case "docs.google.com", "drive.google.com":
+	default:
+		return ""
+	}
+	if u.Path == "/open" {
+		return u.Query().Get("id")
+	}
+	m := driveLinkPath.FindStringSubmatch(u.Path)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// DriveLinks rewrites links to Drive files that were exported with the
+// site, such as Drawings and Slides, to the exported copy.
+type DriveLinks struct {
+	// URLs maps Drive file ID to the URL of the exported file
+	URLs map[string]string
+
+	selector cascadia.Selector
+}
+
+// NewDriveLinks makes a DriveLinks from a map of Drive file ID to URL
+func NewDriveLinks(urls map[string]string) *DriveLinks {
+	return &DriveLinks{
+		URLs:     urls,
+		selector: cascadia.MustCompile("a[href]"),
+	}
+}
+
+func (n *DriveLinks) Run(root *html.Node, log ilog.Logger) error {
+	for _, node := range n.selector.MatchAll(root) {
+		for i, attr := range node.Attr {
+			if attr.Key != "href" {
+				continue
+			}
+			id := DriveFileID(attr.Val)
+			if id == "" {
+				continue
+			}
+			if link, ok := n.URLs[id]; ok {
+				log.Debug("drive link", "url", attr.Val, "to", link)
+				node.Attr[i].Val = link
+			}
+		}
+	}
+	return nil
+}
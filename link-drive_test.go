@@ -0,0 +1,46 @@
+package googledrive2hugo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/client9/ilog"
+	"golang.org/x/net/html"
+)
+
+func TestDriveFileID(t *testing.T) {
+	cases := map[string]string{
+		"https://docs.google.com/drawings/d/1aBc-_9/edit":             "1aBc-_9",
+		"https://docs.google.com/presentation/d/1xyz/edit#slide=id.p": "1xyz",
+		"https://drive.google.com/file/d/1xyz/view?usp=sharing":       "1xyz",
+		"https://drive.google.com/open?id=1xyz":                       "1xyz",
+		"https://docs.google.com/spreadsheets/d/1xyz/edit":            "",
+		"https://www.example.com/drawings/d/1xyz/edit":                "",
+		"/posts/hello-world/":                                         "",
+	}
+	for link, want := range cases {
+		if got := DriveFileID(link); got != want {
+			t.Errorf("DriveFileID(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
+
+func TestDriveLinks(t *testing.T) {
+	doc := `<p><a href="https://docs.google.com/drawings/d/d1/edit">diagram</a> and <a href="https://docs.google.com/presentation/d/other/edit">slides</a></p>`
+	body := newElementNode("body")
+	nodes, err := html.ParseFragment(strings.NewReader(doc), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewDriveLinks(map[string]string{"d1": "/diagrams/arch.svg"})
+	if err := n.Run(nodes[0], &ilog.NopLogger{}); err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.Buffer{}
+	html.Render(&buf, nodes[0])
+	want := `<p><a href="/diagrams/arch.svg">diagram</a> and <a href="https://docs.google.com/presentation/d/other/edit">slides</a></p>`
+	if buf.String() != want {
+		t.Errorf("got %s", buf.String())
+	}
+}
@@ -0,0 +1,362 @@
+package googledrive2hugo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/client9/htmlfmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Markdown rendering of the cleaned up node tree, in CommonMark with
+// the GitHub extensions (tables, strikethrough) and heading IDs that
+// Hugo's Goldmark renderer understands.
+//
+// Anything without a Markdown equivalent is written as HTML, which
+// Hugo only passes through with markup.goldmark.renderer.unsafe set.
+
+var (
+	// shortcodes are passed through as is
+	reShortCodeText = regexp.MustCompile(`{{<.*?>}}|{{%.*?%}}`)
+
+	// "1. " at the start of a paragraph would be a list
+	reListStart = regexp.MustCompile(`^(\d+)([.)])`)
+)
+
+// renderMarkdown writes the children of root as Markdown
+func renderMarkdown(w io.Writer, root *html.Node) error {
+	_, err := io.WriteString(w, mdBlocks(root, "\n\n"))
+	return err
+}
+
+func isMdBlock(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.P, atom.Div, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Pre, atom.Blockquote, atom.Ul, atom.Ol, atom.Table, atom.Hr,
+		atom.Section, atom.Article, atom.Header, atom.Footer, atom.Figure,
+		atom.Dl, atom.Iframe:
+		return true
+	}
+	return false
+}
+
+// mdBlocks renders the children of n as blocks separated by sep.  Runs
+// of inline content become paragraphs.
+func mdBlocks(n *html.Node, sep string) string {
+	var blocks []string
+	var inline bytes.Buffer
+	flush := func() {
+		if p := mdParagraph(inline.String()); p != "" {
+			blocks = append(blocks, p)
+		}
+		inline.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !isMdBlock(c) {
+			inline.WriteString(mdInlineNode(c))
+			continue
+		}
+		flush()
+		if b := mdBlock(c); b != "" {
+			blocks = append(blocks, b)
+		}
+	}
+	flush()
+	return strings.Join(blocks, sep)
+}
+
+func mdBlock(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.P:
+		return mdParagraph(mdInline(n))
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		text := strings.TrimSpace(strings.Replace(mdInline(n), "\\\n", " ", -1))
+		if text == "" {
+			return ""
+		}
+		if id := getAttr(n, "id"); id != "" {
+			text += " {#" + id + "}"
+		}
+		return strings.Repeat("#", level) + " " + text
+	case atom.Pre:
+		return mdCodeBlock(n)
+	case atom.Blockquote:
+		return mdPrefix(mdBlocks(n, "\n\n"), "> ", "> ")
+	case atom.Ul, atom.Ol:
+		return mdList(n)
+	case atom.Table:
+		return mdTable(n)
+	case atom.Hr:
+		return "---"
+	case atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer:
+		return mdBlocks(n, "\n\n")
+	}
+	return mdHTML(n)
+}
+
+// mdParagraph trims a paragraph and escapes anything at the start that
+// would make it a heading, quote or list
+func mdParagraph(s string) string {
+	s = strings.TrimRightFunc(s, unicode.IsSpace)
+	for strings.HasSuffix(s, "\\\n") {
+		s = strings.TrimRightFunc(s[:len(s)-2], unicode.IsSpace)
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	switch s[0] {
+	case '#', '>', '-', '+', '=':
+		return "\\" + s
+	}
+	return reListStart.ReplaceAllString(s, "$1\\$2")
+}
+
+// mdCodeBlock renders a <pre> as a fenced code block.  A
+// "language-xxx" class on the <pre> or its <code> is the info string.
+func mdCodeBlock(n *html.Node) string {
+	lang := mdLanguage(n)
+	if code := n.FirstChild; code != nil && code.DataAtom == atom.Code && lang == "" {
+		lang = mdLanguage(code)
+	}
+	text := strings.TrimSuffix(getTextContent(n), "\n")
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + text + "\n" + fence
+}
+
+func mdLanguage(n *html.Node) string {
+	for _, class := range strings.Fields(getClassAttr(n)) {
+		if strings.HasPrefix(class, "language-") {
+			return class[len("language-"):]
+		}
+	}
+	return ""
+}
+
+// mdList renders a tight list.  Items are indented under their marker.
+func mdList(n *html.Node) string {
+	num := 1
+	if start, err := strconv.Atoi(getAttr(n, "start")); err == nil {
+		num = start
+	}
+	var items []string
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", num)
+			num++
+		}
+		items = append(items, mdPrefix(mdBlocks(li, "\n"), marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+// mdPrefix puts first before the first line, and rest before the
+// others.  Blank lines are not indented.
+func mdPrefix(s string, first string, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			prefix = strings.TrimRightFunc(prefix, unicode.IsSpace)
+		}
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mdTable renders a GitHub style table.  The first row is the header.
+func mdTable(n *html.Node) string {
+	var rows [][]string
+	width := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			case atom.Tr:
+				var row []string
+				for td := c.FirstChild; td != nil; td = td.NextSibling {
+					if td.DataAtom != atom.Td && td.DataAtom != atom.Th {
+						continue
+					}
+					cell := strings.TrimSpace(mdBlocks(td, " "))
+					cell = strings.Replace(cell, "\\\n", "<br>", -1)
+					cell = strings.Replace(cell, "\n", " ", -1)
+					cell = strings.Replace(cell, "|", "\\|", -1)
+					row = append(row, cell)
+				}
+				if len(row) > width {
+					width = len(row)
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+	var lines []string
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", width))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func mdInline(n *html.Node) string {
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		buf.WriteString(mdInlineNode(c))
+	}
+	return buf.String()
+}
+
+func mdInlineNode(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return mdEscape(strings.Replace(n.Data, "\n", " ", -1))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	switch n.DataAtom {
+	case atom.B, atom.Strong:
+		return mdWrap(mdInline(n), "**")
+	case atom.I, atom.Em:
+		return mdWrap(mdInline(n), "*")
+	case atom.S, atom.Strike, atom.Del:
+		return mdWrap(mdInline(n), "~~")
+	case atom.Code:
+		return mdCodeSpan(getTextContent(n))
+	case atom.A:
+		text := mdInline(n)
+		href := getHrefAttr(n)
+		if href == "" {
+			// keep bookmarks, so links to them work
+			if getAttr(n, "id") != "" {
+				return mdHTML(n)
+			}
+			return text
+		}
+		return "[" + text + "](" + mdURL(href) + mdTitle(n) + ")"
+	case atom.Img:
+		return "![" + mdEscape(getAttr(n, "alt")) + "](" + mdURL(getAttr(n, "src")) + mdTitle(n) + ")"
+	case atom.Br:
+		return "\\\n"
+	case atom.Span, atom.Font, atom.Small, atom.Big, atom.Label, atom.Abbr, atom.Cite, atom.Q:
+		return mdInline(n)
+	}
+	if isMdBlock(n) {
+		return mdBlock(n)
+	}
+	return mdHTML(n)
+}
+
+// mdWrap puts marker around s, keeping leading and trailing spaces
+// outside, since "** bold **" is not bold
+func mdWrap(s string, marker string) string {
+	inner := strings.TrimSpace(s)
+	if inner == "" {
+		return s
+	}
+	start := strings.Index(s, inner)
+	return s[:start] + marker + inner + marker + s[start+len(inner):]
+}
+
+// mdCodeSpan uses enough backticks to hold any backticks in s
+func mdCodeSpan(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+func mdURL(s string) string {
+	if strings.ContainsAny(s, " ()<>") && !reShortCodeText.MatchString(s) {
+		return "<" + s + ">"
+	}
+	return s
+}
+
+func mdTitle(n *html.Node) string {
+	title := getAttr(n, "title")
+	if title == "" {
+		return ""
+	}
+	return " " + strconv.Quote(title)
+}
+
+// mdEscape escapes text so it isn't taken as Markdown.  Shortcodes
+// are left alone, and "_" is only escaped where it could start or end
+// emphasis, so snake_case stays readable.
+func mdEscape(s string) string {
+	var buf bytes.Buffer
+	last := 0
+	for _, loc := range reShortCodeText.FindAllStringIndex(s, -1) {
+		mdEscapeText(&buf, s[last:loc[0]])
+		buf.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	mdEscapeText(&buf, s[last:])
+	return buf.String()
+}
+
+func mdEscapeText(buf *bytes.Buffer, s string) {
+	runes := []rune(s)
+	for i, r := range runes {
+		switch r {
+		case '\\', '`', '*', '[', ']', '<':
+			buf.WriteByte('\\')
+		case '_':
+			inWord := i > 0 && i < len(runes)-1 && isWordRune(runes[i-1]) && isWordRune(runes[i+1])
+			if !inWord {
+				buf.WriteByte('\\')
+			}
+		}
+		buf.WriteRune(r)
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// mdHTML renders a node as HTML
+func mdHTML(n *html.Node) string {
+	var buf bytes.Buffer
+	if err := htmlfmt.Render(&buf, n, "", ""); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(unescapeShortcodes(buf.Bytes())))
+}
@@ -0,0 +1,67 @@
+package googledrive2hugo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/client9/ilog"
+	"golang.org/x/net/html"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	cases := []struct {
+		html string
+		want string
+	}{
+		{`<h2 id="h.abc">Hello <i>World</i></h2>`, "## Hello *World* {#h.abc}"},
+		{`<p>Some <b>bold </b>and <code>x := 1</code> with snake_case and *stars*</p>`, "Some **bold** and `x := 1` with snake_case and \\*stars\\*"},
+		{`<p># not a heading</p><p>1. not a list</p>`, "\\# not a heading\n\n1\\. not a list"},
+		{`<p><a href="https://example.com/">link</a> and <img src="a.png" alt="pic"></p>`, "[link](https://example.com/) and ![pic](a.png)"},
+		{`<p><a id="id.xyz"></a>Bookmark</p>`, `<a id="id.xyz"></a>Bookmark`},
+		{`<p>line one<br>line two</p>`, "line one\\\nline two"},
+		{`<pre><code>if a {
+	return "` + "```" + `"
+}</code></pre>`, "````\nif a {\n\treturn \"```\"\n}\n````"},
+		{`<blockquote><p>quoted</p><p>twice</p></blockquote>`, "> quoted\n>\n> twice"},
+		{`<ul><li>one</li><li>two<ol><li>a</li><li>b</li></ol></li></ul>`, "- one\n- two\n  1. a\n  2. b"},
+		{`<table><thead><tr><th>plan</th><th>price</th></tr></thead><tbody><tr><td>Basic | Pro</td><td>10</td></tr></tbody></table>`,
+			"| plan | price |\n| --- | --- |\n| Basic \\| Pro | 10 |"},
+		{`<p>{{< youtube id="a_b" >}}</p>`, `{{< youtube id="a_b" >}}`},
+		{`<p>x<sup>2</sup></p>`, "x<sup>2</sup>"},
+	}
+	body := newElementNode("body")
+	for _, tt := range cases {
+		nodes, err := html.ParseFragment(strings.NewReader(tt.html), body)
+		if err != nil {
+			t.Fatalf("unable to parse %q", tt.html)
+		}
+		root := newElementNode("body")
+		for _, n := range nodes {
+			root.AppendChild(n)
+		}
+		buf := bytes.Buffer{}
+		if err := renderMarkdown(&buf, root); err != nil {
+			t.Fatalf("unable to render %q: %s", tt.html, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s\nwant:\n%s\ngot:\n%s", tt.html, tt.want, buf.String())
+		}
+	}
+}
+
+func TestToMarkdown(t *testing.T) {
+	doc := `<html><body><p><span>---</span></p><p><span>title: Hello</span></p><p><span>---</span></p>` +
+		`<p><span>Run this:</span></p><p><code>make test</code></p></body></html>`
+	c := Converter{Logger: &ilog.NopLogger{}}
+	out, err := c.ToMarkdown([]byte(doc), map[string]interface{}{"date": "2019-01-02T03:04:05Z"})
+	if err != nil {
+		t.Fatalf("unable to convert: %s", err)
+	}
+	if !strings.HasSuffix(string(out), "---\nRun this:\n\n```\nmake test\n```") {
+		t.Errorf("got:\n%s", out)
+	}
+	if !strings.Contains(string(out), "title: Hello\n") {
+		t.Errorf("missing front matter:\n%s", out)
+	}
+}
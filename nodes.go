@@ -75,6 +75,15 @@ func setAltAttr(root *html.Node, alt string) {
 	}
 }
 
+func getAttr(root *html.Node, key string) string {
+	for _, attr := range root.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
 func getClassAttr(root *html.Node) string {
 	for _, attr := range root.Attr {
 		if attr.Key == "class" {
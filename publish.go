@@ -0,0 +1,42 @@
+package googledrive2hugo
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// PublishPolicy decides if a doc is ready to publish.  If not, it
+// returns the reason why.
+type PublishPolicy func(f *drive.File) (bool, string)
+
+// ParsePublishPolicy makes a PublishPolicy from its name:
+//
+//	all             publish every doc
+//	starred         publish starred docs
+//	property:<key>  publish docs with Drive property <key> set to "true"
+func ParsePublishPolicy(name string) (PublishPolicy, error) {
+	switch {
+	case name == "all":
+		return func(f *drive.File) (bool, string) {
+			return true, ""
+		}, nil
+	case name == "starred":
+		return func(f *drive.File) (bool, string) {
+			if f.Starred {
+				return true, ""
+			}
+			return false, "not starred"
+		}, nil
+	case strings.HasPrefix(name, "property:") && len(name) > len("property:"):
+		key := name[len("property:"):]
+		return func(f *drive.File) (bool, string) {
+			if f.Properties[key] == "true" {
+				return true, ""
+			}
+			return false, fmt.Sprintf("property %q is not true", key)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown publish policy %q", name)
+}
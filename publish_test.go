@@ -0,0 +1,42 @@
+package googledrive2hugo
+
+import (
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+)
+
+func TestPublishPolicy(t *testing.T) {
+	ready := &drive.File{Starred: true, Properties: map[string]string{"ready": "true"}}
+	wip := &drive.File{Properties: map[string]string{"ready": "no"}}
+	cases := []struct {
+		policy string
+		ready  bool
+		wip    bool
+	}{
+		{"all", true, true},
+		{"starred", true, false},
+		{"property:ready", true, false},
+	}
+	for _, tt := range cases {
+		policy, err := ParsePublishPolicy(tt.policy)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.policy, err)
+		}
+		if ok, _ := policy(ready); ok != tt.ready {
+			t.Errorf("%s: ready doc got %v", tt.policy, ok)
+		}
+		ok, reason := policy(wip)
+		if ok != tt.wip {
+			t.Errorf("%s: wip doc got %v", tt.policy, ok)
+		}
+		if !ok && reason == "" {
+			t.Errorf("%s: no reason given", tt.policy)
+		}
+	}
+	for _, name := range []string{"", "property:", "label:ready"} {
+		if _, err := ParsePublishPolicy(name); err == nil {
+			t.Errorf("expected error for %q", name)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package googledrive2hugo
+
+import (
+	"fmt"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// Reviser is a DriveSource that can export earlier revisions of a
+// file, such as DriveService
+type Reviser interface {
+	// Revisions returns the revisions of a file, oldest first
+	Revisions(f *drive.File) ([]*drive.Revision, error)
+
+	// ExportRevision returns a revision of a file converted to
+	// mimeType
+	ExportRevision(f *drive.File, revisionID string, mimeType string) ([]byte, error)
+}
+
+// KeptRevision returns the latest revision of a file that was kept
+// (marked keepForever) or published, or nil if there is none.  Editors
+// use this to "cut" the version that goes live.  The source must be a
+// Reviser.
+func KeptRevision(src DriveSource, f *drive.File) (*drive.Revision, error) {
+	reviser, ok := src.(Reviser)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support revisions", src)
+	}
+	revs, err := reviser.Revisions(f)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(revs) - 1; i >= 0; i-- {
+		if revs[i].KeepForever || revs[i].Published {
+			return revs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// AtRevision returns a DriveSource that exports revision revisionID
+// of f instead of its head revision, so ExportHTML or ExportZip can be
+// used on it.  Other files are exported as usual.  The source must be
+// a Reviser.
+func AtRevision(src DriveSource, f *drive.File, revisionID string) (DriveSource, error) {
+	reviser, ok := src.(Reviser)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support revisions", src)
+	}
+	return &revisionSource{
+		DriveSource: src,
+		reviser:     reviser,
+		id:          TargetID(f),
+		revisionID:  revisionID,
+	}, nil
+}
+
+type revisionSource struct {
+	DriveSource
+	reviser    Reviser
+	id         string
+	revisionID string
+}
+
+func (r *revisionSource) Export(f *drive.File, mimeType string) ([]byte, error) {
+	if TargetID(f) != r.id {
+		return r.DriveSource.Export(f, mimeType)
+	}
+	return r.reviser.ExportRevision(f, r.revisionID, mimeType)
+}
@@ -0,0 +1,166 @@
+package googledrive2hugo
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/gohugoio/hugo/parser"
+	"github.com/gohugoio/hugo/parser/metadecoders"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"google.golang.org/api/drive/v3"
+)
+
+const mimeSpreadsheet = "application/vnd.google-apps.spreadsheet"
+
+var selectorSheetRow = cascadia.MustCompile("table tbody tr")
+
+func IsSpreadsheet(f *drive.File) bool {
+	return f.MimeType == mimeSpreadsheet
+}
+
+// Sheet is one tab of a Google Sheet.  The first row is the header.
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+// ExportSheets downloads every tab of a Google Sheet.
+//
+// Drive can only export the first tab as CSV, so the sheet is
+// exported as zipped HTML, which has one HTML file per tab, and the
+// tables are read back out.
+func ExportSheets(src DriveSource, f *drive.File) ([]Sheet, error) {
+	raw, err := src.Export(f, "application/zip")
+	if err != nil {
+		return nil, err
+	}
+	r, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return nil, err
+	}
+	var out []Sheet
+	for _, zf := range r.File {
+		if path.Dir(zf.Name) != "." || path.Ext(zf.Name) != ".html" {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		rows, err := parseSheet(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", zf.Name, err)
+		}
+		out = append(out, Sheet{
+			Name: strings.TrimSuffix(zf.Name, ".html"),
+			Rows: rows,
+		})
+	}
+	return out, nil
+}
+
+// parseSheet reads the table from the HTML export of one tab.  The row
+// and column headers ("1", "A") are <th> and are skipped, as are empty
+// rows.  Columns past the last header are dropped.
+func parseSheet(raw []byte) ([][]string, error) {
+	root, err := html.Parse(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]string
+	width := 0
+	for _, tr := range selectorSheetRow.MatchAll(root) {
+		var row []string
+		empty := true
+		for td := tr.FirstChild; td != nil; td = td.NextSibling {
+			if td.DataAtom != atom.Td {
+				continue
+			}
+			text := strings.TrimSpace(getTextContent(td))
+			if text != "" {
+				empty = false
+			}
+			row = append(row, text)
+		}
+		if empty {
+			continue
+		}
+		if rows == nil {
+			// header row sets the width
+			for i, key := range row {
+				if key != "" {
+					width = i + 1
+				}
+			}
+		}
+		for len(row) < width {
+			row = append(row, "")
+		}
+		rows = append(rows, row[:width])
+	}
+	return rows, nil
+}
+
+// Records returns each row after the header as a map of header to
+// value
+func (s *Sheet) Records() []map[string]interface{} {
+	out := []map[string]interface{}{}
+	if len(s.Rows) == 0 {
+		return out
+	}
+	keys := s.Rows[0]
+	for _, row := range s.Rows[1:] {
+		rec := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			if key != "" {
+				rec[key] = row[i]
+			}
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+// IsDataFormat reports whether Write supports the format
+func IsDataFormat(format string) bool {
+	switch format {
+	case "csv", "json", "yaml":
+		return true
+	}
+	return false
+}
+
+// Write writes the tab as a Hugo data file, in "csv", "json" or "yaml"
+// format.  JSON and YAML are a list of records, see Records.
+func (s *Sheet) Write(w io.Writer, format string) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(s.Rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s.Records())
+	case "yaml":
+		return parser.InterfaceToConfig(s.Records(), metadecoders.YAML, w)
+	}
+	return fmt.Errorf("unknown data format %q", format)
+}
@@ -0,0 +1,61 @@
+package googledrive2hugo
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// abbreviated from a Google Sheets HTML export
+const sheetHTML = `<html><body><div class="ritz grid-container" dir="ltr"><table class="waffle" cellspacing="0" cellpadding="0">
+<thead><tr><th class="row-header freezebar-origin-ltr"></th><th id="0C0" class="column-headers-background">A</th><th id="0C1" class="column-headers-background">B</th><th id="0C2" class="column-headers-background">C</th></tr></thead>
+<tbody>
+<tr style="height: 20px"><th id="0R0" class="row-headers-background"><div class="row-header-wrapper">1</div></th><td class="s0">plan</td><td class="s0">price</td><td></td></tr>
+<tr><th class="freezebar-cell freezebar-horizontal-handle"></th><td class="freezebar-cell freezebar-horizontal"></td><td class="freezebar-cell freezebar-horizontal"></td><td class="freezebar-cell freezebar-horizontal"></td></tr>
+<tr style="height: 20px"><th id="0R1" class="row-headers-background"><div class="row-header-wrapper">2</div></th><td class="s1">Basic</td><td class="s2">10</td><td></td></tr>
+<tr style="height: 20px"><th id="0R2" class="row-headers-background"><div class="row-header-wrapper">3</div></th><td class="s1"><div class="softmerge-inner">Pro &amp; more</div></td><td class="s2">25</td><td></td></tr>
+<tr style="height: 20px"><th id="0R3" class="row-headers-background"><div class="row-header-wrapper">4</div></th><td></td><td></td><td></td></tr>
+</tbody></table></div></body></html>`
+
+func TestParseSheet(t *testing.T) {
+	rows, err := parseSheet([]byte(sheetHTML))
+	if err != nil {
+		t.Fatalf("unable to parse: %s", err)
+	}
+	want := [][]string{
+		{"plan", "price"},
+		{"Basic", "10"},
+		{"Pro & more", "25"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("want %q got %q", want, rows)
+	}
+
+	sheet := Sheet{Name: "plans", Rows: rows}
+	buf := bytes.Buffer{}
+	if err := sheet.Write(&buf, "json"); err != nil {
+		t.Fatalf("unable to write json: %s", err)
+	}
+	wantJSON := `[
+  {
+    "plan": "Basic",
+    "price": "10"
+  },
+  {
+    "plan": "Pro & more",
+    "price": "25"
+  }
+]
+`
+	if buf.String() != wantJSON {
+		t.Errorf("got json %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := sheet.Write(&buf, "csv"); err != nil {
+		t.Fatalf("unable to write csv: %s", err)
+	}
+	if buf.String() != "plan,price\nBasic,10\nPro & more,25\n" {
+		t.Errorf("got csv %q", buf.String())
+	}
+}
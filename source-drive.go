@@ -0,0 +1,291 @@
+package googledrive2hugo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/slides/v1"
+)
+
+const (
+	driveGetFields = "id,name,mimeType,createdTime,modifiedTime,description,shortcutDetails,trashed," +
+		"properties,appProperties,owners(displayName,emailAddress)," +
+		"lastModifyingUser(displayName,emailAddress),webViewLink,starred"
+	driveFileFields = "nextPageToken,files(" + driveGetFields + ")"
+
+	driveRevisionFields = "nextPageToken,revisions(id,modifiedTime,keepForever,published,exportLinks)"
+	driveChangeFields   = "nextPageToken,newStartPageToken,changes(fileId,removed,file(id,mimeType,parents,trashed))"
+)
+
+// DriveService is a DriveSource backed by the Google Drive v3 API
+type DriveService struct {
+	Service *drive.Service
+
+	// DriveID is the shared drive (Team Drive) to use.  If empty
+	// "My Drive" of the user is used.
+	DriveID string
+
+	// Client is the authorized client used to download revisions,
+	// typically the one returned by Client.  Drive only exports
+	// revisions of Google Docs through links, not the API.
+	Client *http.Client
+
+	// Slides is used to export each slide of a deck as an image,
+	// which Drive can't do
+	Slides *slides.Service
+}
+
+// NewDriveService makes a DriveSource from a Google Drive service,
+// typically the one returned by Setup
+func NewDriveService(srv *drive.Service) *DriveService {
+	return &DriveService{
+		Service: srv,
+	}
+}
+
+// List returns all the children of a folder that are not in the
+// trash, following every page of results
+func (d *DriveService) List(folder *drive.File) ([]*drive.File, error) {
+	return allPages(func(token string) ([]*drive.File, string, error) {
+		return d.ListPage(folder, token)
+	})
+}
+
+// ListPage returns one page of the children of a folder, and the token
+// for the next page, empty after the last page
+func (d *DriveService) ListPage(folder *drive.File, pageToken string) ([]*drive.File, string, error) {
+	query := fmt.Sprintf("'%s' in parents and trashed = false", EscapeQuery(TargetID(folder)))
+	return d.SearchPage(query, pageToken)
+}
+
+// Search returns all files matching a Drive query, following every
+// page of results.  See
+// https://developers.google.com/drive/api/v3/search-files
+func (d *DriveService) Search(query string) ([]*drive.File, error) {
+	return allPages(func(token string) ([]*drive.File, string, error) {
+		return d.SearchPage(query, token)
+	})
+}
+
+// SearchPage returns one page of the files matching a Drive query,
+// and the token for the next page, empty after the last page
+func (d *DriveService) SearchPage(query string, pageToken string) ([]*drive.File, string, error) {
+	call := d.list().Fields(driveFileFields).Q(query).PageSize(1000)
+	if pageToken != "" {
+		call.PageToken(pageToken)
+	}
+	r, err := call.Do()
+	if err != nil {
+		return nil, "", err
+	}
+	return r.Files, r.NextPageToken, nil
+}
+
+// allPages calls page until there is no next page
+func allPages(page func(pageToken string) ([]*drive.File, string, error)) ([]*drive.File, error) {
+	var out []*drive.File
+	token := ""
+	for {
+		files, next, err := page(token)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, files...)
+		if next == "" {
+			return out, nil
+		}
+		token = next
+	}
+}
+
+// Resolve finds a folder by path, starting from the top of the drive.
+// A "/" in a folder name can be escaped as "\/".  Use Get or WalkID
+// if more than one folder has the same name.
+func (d *DriveService) Resolve(root string) (*drive.File, error) {
+	parent := "root"
+	if d.DriveID != "" {
+		parent = d.DriveID
+	}
+
+	// top of the drive itself
+	if root == "" {
+		return d.Get(parent)
+	}
+
+	var info *drive.File
+	for _, dir := range SplitPath(root) {
+		query := fmt.Sprintf("name = '%s' and mimeType = '%s' and '%s' in parents and trashed = false",
+			EscapeQuery(dir), mimeFolder, EscapeQuery(parent))
+		files, err := d.Search(query)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%q not found", root)
+		}
+		if len(files) > 1 {
+			return nil, fmt.Errorf("more than one folder named %q found in %q, use the folder ID", dir, root)
+		}
+		info = files[0]
+		parent = info.Id
+	}
+	return info, nil
+}
+
+func (d *DriveService) Get(id string) (*drive.File, error) {
+	return d.Service.Files.Get(id).Fields(driveGetFields).SupportsAllDrives(true).Do()
+}
+
+// list starts a Files.List call in the right corpus, with shared
+// drive support turned on
+func (d *DriveService) list() *drive.FilesListCall {
+	call := d.Service.Files.List().Spaces("drive").SupportsAllDrives(true).IncludeItemsFromAllDrives(true)
+	if d.DriveID != "" {
+		return call.Corpora("drive").DriveId(d.DriveID)
+	}
+	return call.Corpora("user")
+}
+
+// UseSharedDrive sets the shared drive to walk, by ID or by name
+func (d *DriveService) UseSharedDrive(nameOrID string) error {
+	if sd, err := d.Service.Drives.Get(nameOrID).Do(); err == nil {
+		d.DriveID = sd.Id
+		return nil
+	}
+	query := fmt.Sprintf("name = '%s'", EscapeQuery(nameOrID))
+	r, err := d.Service.Drives.List().Q(query).Do()
+	if err != nil {
+		return err
+	}
+	if len(r.Drives) == 0 {
+		return fmt.Errorf("shared drive %q not found", nameOrID)
+	}
+	if len(r.Drives) > 1 {
+		return fmt.Errorf("more than one shared drive named %q found", nameOrID)
+	}
+	d.DriveID = r.Drives[0].Id
+	return nil
+}
+
+func (d *DriveService) Export(f *drive.File, mimeType string) ([]byte, error) {
+	resp, err := d.Service.Files.Export(TargetID(f), mimeType).Download()
+	if err != nil {
+		return nil, err
+	}
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return out, resp.Body.Close()
+}
+
+// Revisions returns all the revisions of a file, oldest first
+func (d *DriveService) Revisions(f *drive.File) ([]*drive.Revision, error) {
+	var out []*drive.Revision
+	call := d.Service.Revisions.List(TargetID(f)).Fields(driveRevisionFields).PageSize(1000)
+	for {
+		r, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r.Revisions...)
+		if r.NextPageToken == "" {
+			return out, nil
+		}
+		call.PageToken(r.NextPageToken)
+	}
+}
+
+// ExportRevision downloads a revision of a file converted to
+// mimeType, using its export link.  Client must be set.
+func (d *DriveService) ExportRevision(f *drive.File, revisionID string, mimeType string) ([]byte, error) {
+	if d.Client == nil {
+		return nil, fmt.Errorf("no client to export revisions with")
+	}
+	rev, err := d.Service.Revisions.Get(TargetID(f), revisionID).Fields("id,exportLinks").Do()
+	if err != nil {
+		return nil, err
+	}
+	link, ok := rev.ExportLinks[mimeType]
+	if !ok {
+		return nil, fmt.Errorf("%s: unable to export revision %s as %q", f.Name, revisionID, mimeType)
+	}
+	return d.download(link)
+}
+
+// ExportSlides downloads a PNG of every slide of a deck, in order,
+// using the Slides API thumbnails.  Client and Slides must be set.
+func (d *DriveService) ExportSlides(f *drive.File) ([][]byte, error) {
+	if d.Client == nil || d.Slides == nil {
+		return nil, fmt.Errorf("no slides service to export slides with")
+	}
+	deck, err := d.Slides.Presentations.Get(TargetID(f)).Fields("slides(objectId)").Do()
+	if err != nil {
+		return nil, err
+	}
+	var out [][]byte
+	for _, page := range deck.Slides {
+		thumb, err := d.Slides.Presentations.Pages.GetThumbnail(TargetID(f), page.ObjectId).
+			ThumbnailPropertiesMimeType("PNG").ThumbnailPropertiesThumbnailSize("LARGE").Do()
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.download(thumb.ContentUrl)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, raw)
+	}
+	return out, nil
+}
+
+// download gets a link with the authorized client
+func (d *DriveService) download(link string) ([]byte, error) {
+	resp, err := d.Client.Get(link)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// StartPageToken returns the token for changes made from now on
+func (d *DriveService) StartPageToken() (string, error) {
+	call := d.Service.Changes.GetStartPageToken().SupportsAllDrives(true)
+	if d.DriveID != "" {
+		call.DriveId(d.DriveID)
+	}
+	r, err := call.Do()
+	if err != nil {
+		return "", err
+	}
+	return r.StartPageToken, nil
+}
+
+// Changes returns all the changes since token, following every page,
+// and the token for the changes after them
+func (d *DriveService) Changes(token string) ([]*drive.Change, string, error) {
+	var out []*drive.Change
+	for {
+		call := d.Service.Changes.List(token).Fields(driveChangeFields).PageSize(1000).
+			Spaces("drive").SupportsAllDrives(true).IncludeItemsFromAllDrives(true)
+		if d.DriveID != "" {
+			call.DriveId(d.DriveID)
+		}
+		r, err := call.Do()
+		if err != nil {
+			return nil, "", err
+		}
+		out = append(out, r.Changes...)
+		if r.NextPageToken == "" {
+			return out, r.NewStartPageToken, nil
+		}
+		token = r.NextPageToken
+	}
+}
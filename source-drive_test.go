@@ -0,0 +1,202 @@
+package googledrive2hugo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+	"google.golang.org/api/slides/v1"
+)
+
+// serves a folder of 5 files, two at a time
+func TestDriveServiceListPages(t *testing.T) {
+	const total = 5
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		start := 0
+		if tok := r.URL.Query().Get("pageToken"); tok != "" {
+			fmt.Sscanf(tok, "%d", &start)
+		}
+		end := start + 2
+		next := ""
+		if end < total {
+			next = fmt.Sprintf(`"nextPageToken": "%d",`, end)
+		} else {
+			end = total
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{%s "files": [`, next)
+		for i := start; i < end; i++ {
+			if i != start {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"id": "%d", "name": "doc%d", "mimeType": %q}`, i, i, mimeDocument)
+		}
+		fmt.Fprint(w, "]}")
+	}))
+	defer ts.Close()
+
+	srv, err := drive.NewService(context.Background(), option.WithEndpoint(ts.URL), option.WithHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatalf("unable to make service: %s", err)
+	}
+	files, err := NewDriveService(srv).List(&drive.File{Id: "folder"})
+	if err != nil {
+		t.Fatalf("list failed: %s", err)
+	}
+	if len(files) != total {
+		t.Errorf("expected %d files, got %d", total, len(files))
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 pages, got %d", calls)
+	}
+}
+
+func TestDriveServiceSharedDrive(t *testing.T) {
+	var got url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"files": []}`)
+	}))
+	defer ts.Close()
+
+	srv, err := drive.NewService(context.Background(), option.WithEndpoint(ts.URL), option.WithHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatalf("unable to make service: %s", err)
+	}
+	d := NewDriveService(srv)
+	d.DriveID = "team"
+	if _, err := d.List(&drive.File{Id: "folder"}); err != nil {
+		t.Fatalf("list failed: %s", err)
+	}
+	want := map[string]string{
+		"corpora":                   "drive",
+		"driveId":                   "team",
+		"supportsAllDrives":         "true",
+		"includeItemsFromAllDrives": "true",
+		"q":                         "'folder' in parents and trashed = false",
+	}
+	for k, v := range want {
+		if got.Get(k) != v {
+			t.Errorf("%s: want %q got %q", k, v, got.Get(k))
+		}
+	}
+}
+
+func TestDriveServiceRevision(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/doc/revisions":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"revisions": [{"id": "1", "keepForever": true}, {"id": "2", "keepForever": true}, {"id": "3"}]}`)
+		case "/files/doc/revisions/2":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id": "2", "exportLinks": {"text/html": "%s/export?rev=2"}}`, ts.URL)
+		case "/export":
+			fmt.Fprintf(w, "<p>revision %s</p>", r.URL.Query().Get("rev"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	srv, err := drive.NewService(context.Background(), option.WithEndpoint(ts.URL), option.WithHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatalf("unable to make service: %s", err)
+	}
+	d := NewDriveService(srv)
+	d.Client = ts.Client()
+	f := &drive.File{Id: "doc"}
+	rev, err := KeptRevision(d, f)
+	if err != nil {
+		t.Fatalf("unable to get revisions: %s", err)
+	}
+	if rev == nil || rev.Id != "2" {
+		t.Fatalf("expected revision 2, got %v", rev)
+	}
+	src, err := AtRevision(d, f, rev.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ExportHTML(src, f)
+	if err != nil {
+		t.Fatalf("unable to export revision: %s", err)
+	}
+	if string(out) != "<p>revision 2</p>" {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestDriveServiceChanges(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("pageToken") {
+		case "10":
+			fmt.Fprint(w, `{"nextPageToken": "11", "changes": [{"fileId": "a"}]}`)
+		case "11":
+			fmt.Fprint(w, `{"newStartPageToken": "12", "changes": [{"fileId": "b", "removed": true}]}`)
+		default:
+			http.Error(w, "bad token", http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	srv, err := drive.NewService(context.Background(), option.WithEndpoint(ts.URL), option.WithHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatalf("unable to make service: %s", err)
+	}
+	changes, next, err := NewDriveService(srv).Changes("10")
+	if err != nil {
+		t.Fatalf("changes failed: %s", err)
+	}
+	if len(changes) != 2 || changes[0].FileId != "a" || !changes[1].Removed {
+		t.Errorf("unexpected changes %v", changes)
+	}
+	if next != "12" {
+		t.Errorf("expected next token 12, got %q", next)
+	}
+}
+
+func TestDriveServiceExportSlides(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/presentations/deck":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"slides": [{"objectId": "p1"}, {"objectId": "p2"}]}`)
+		case "/v1/presentations/deck/pages/p1/thumbnail", "/v1/presentations/deck/pages/p2/thumbnail":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"contentUrl": "%s/thumb?page=%s"}`, ts.URL, path.Base(path.Dir(r.URL.Path)))
+		case "/thumb":
+			fmt.Fprintf(w, "png %s", r.URL.Query().Get("page"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	srv, err := slides.NewService(context.Background(), option.WithEndpoint(ts.URL), option.WithHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatalf("unable to make service: %s", err)
+	}
+	d := &DriveService{Client: ts.Client(), Slides: srv}
+	out, err := ExportSlides(d, &drive.File{Id: "deck"})
+	if err != nil {
+		t.Fatalf("unable to export slides: %s", err)
+	}
+	if len(out) != 2 || string(out[0]) != "png p1" || string(out[1]) != "png p2" {
+		t.Errorf("got %q", out)
+	}
+	if !IsSlideImage(SlideImage(2)) || IsSlideImage("slide-x.png") {
+		t.Errorf("slide image names don't match")
+	}
+}
@@ -0,0 +1,177 @@
+package googledrive2hugo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// LocalSource is a DriveSource backed by a local directory of Google
+// Docs that were already exported as HTML ("Download as Web page").
+// Each sub-directory is a folder and each ".html" or ".zip" (zipped
+// HTML with images) file is a Google Doc named without the extension.
+// Symbolic links are shortcuts.  Other files are ignored.
+//
+// File IDs are slash separated paths relative to Root.  Created and
+// modified times are both taken from the file modification time.
+//
+// Mostly used for testing and CI, where there is no OAuth.
+type LocalSource struct {
+	Root string
+}
+
+// NewLocalSource makes a DriveSource from a directory
+func NewLocalSource(root string) *LocalSource {
+	return &LocalSource{
+		Root: root,
+	}
+}
+
+func (l *LocalSource) List(folder *drive.File) ([]*drive.File, error) {
+	dir := TargetID(folder)
+	entries, err := ioutil.ReadDir(l.filename(dir))
+	if err != nil {
+		return nil, err
+	}
+	var out []*drive.File
+	for _, fi := range entries {
+		if strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		id := path.Join(dir, fi.Name())
+		if fi.Mode()&os.ModeSymlink != 0 {
+			shortcut, err := l.shortcut(id)
+			if err != nil {
+				return nil, err
+			}
+			if shortcut != nil {
+				out = append(out, shortcut)
+			}
+			continue
+		}
+		if !fi.IsDir() && !isLocalDoc(fi.Name()) {
+			continue
+		}
+		out = append(out, l.fileInfo(id, fi))
+	}
+	return out, nil
+}
+
+func isLocalDoc(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".html" || ext == ".zip"
+}
+
+// shortcut turns a symbolic link into a Drive shortcut.  Links to
+// something other than a folder or doc are ignored.
+func (l *LocalSource) shortcut(id string) (*drive.File, error) {
+	link, err := os.Readlink(l.filename(id))
+	if err != nil {
+		return nil, err
+	}
+	if filepath.IsAbs(link) {
+		if link, err = filepath.Rel(l.Root, link); err != nil {
+			return nil, err
+		}
+		link = filepath.ToSlash(link)
+	} else {
+		link = path.Join(path.Dir(id), filepath.ToSlash(link))
+	}
+	target, err := l.Get(link)
+	if err != nil {
+		return nil, err
+	}
+	if !IsDir(target) && !isLocalDoc(link) {
+		return nil, nil
+	}
+	name := path.Base(id)
+	if isLocalDoc(name) {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	return &drive.File{
+		Id:       id,
+		Name:     name,
+		MimeType: mimeShortcut,
+		ShortcutDetails: &drive.FileShortcutDetails{
+			TargetId:       target.Id,
+			TargetMimeType: target.MimeType,
+		},
+	}, nil
+}
+
+func (l *LocalSource) Get(id string) (*drive.File, error) {
+	fi, err := os.Stat(l.filename(id))
+	if err != nil {
+		return nil, err
+	}
+	return l.fileInfo(path.Clean(id), fi), nil
+}
+
+func (l *LocalSource) Resolve(root string) (*drive.File, error) {
+	id := path.Clean("/" + root)[1:]
+	if id == "" {
+		id = "."
+	}
+	fi, err := os.Stat(l.filename(id))
+	if err != nil {
+		return nil, fmt.Errorf("%q not found", root)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%q is not a folder", root)
+	}
+	return l.fileInfo(id, fi), nil
+}
+
+// Export returns a .html doc as "text/html", and a .zip doc as
+// either "application/zip" or "text/html"
+func (l *LocalSource) Export(f *drive.File, mimeType string) ([]byte, error) {
+	if IsDir(f) {
+		return nil, fmt.Errorf("%s: unable to export a folder", f.Id)
+	}
+	id := TargetID(f)
+	zipped := filepath.Ext(id) == ".zip"
+	switch {
+	case mimeType == "text/html" && !zipped:
+		return ioutil.ReadFile(l.filename(id))
+	case mimeType == "text/html" && zipped:
+		raw, err := ioutil.ReadFile(l.filename(id))
+		if err != nil {
+			return nil, err
+		}
+		markup, _, err := unzipHTML(raw)
+		return markup, err
+	case mimeType == "application/zip" && zipped:
+		return ioutil.ReadFile(l.filename(id))
+	}
+	return nil, fmt.Errorf("%s: unable to export as %q", f.Id, mimeType)
+}
+
+func (l *LocalSource) filename(id string) string {
+	return filepath.Join(l.Root, filepath.FromSlash(id))
+}
+
+func (l *LocalSource) fileInfo(id string, fi os.FileInfo) *drive.File {
+	name := fi.Name()
+	mimeType := mimeFolder
+	if !fi.IsDir() {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+		mimeType = mimeDocument
+	}
+	if id == "." {
+		name = filepath.Base(l.Root)
+	}
+	mtime := fi.ModTime().UTC().Format(time.RFC3339)
+	return &drive.File{
+		Id:           id,
+		Name:         name,
+		MimeType:     mimeType,
+		CreatedTime:  mtime,
+		ModifiedTime: mtime,
+	}
+}
@@ -0,0 +1,120 @@
+package googledrive2hugo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/client9/ilog"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestLocalSourceWalk(t *testing.T) {
+	var got []string
+	fn := func(src DriveSource, path string, info *drive.File, err error) error {
+		if err != nil {
+			t.Fatalf("walk error on %q: %s", path, err)
+		}
+		got = append(got, path)
+		return nil
+	}
+	stats, err := Walk(NewLocalSource("testdata"), "drive", fn)
+	if err != nil {
+		t.Fatalf("walk failed: %s", err)
+	}
+	want := []string{"", "about", "posts", "posts/hello-world"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+	wantStats := WalkStats{"": 2, "posts": 1}
+	if !reflect.DeepEqual(stats, wantStats) {
+		t.Errorf("want stats %v got %v", wantStats, stats)
+	}
+}
+
+func TestLocalSourceConvert(t *testing.T) {
+	src := NewLocalSource("testdata/drive")
+	var out []byte
+	fn := func(src DriveSource, path string, info *drive.File, err error) error {
+		if err != nil || !IsGoogleDoc(info) {
+			return err
+		}
+		raw, err := ExportHTML(src, info)
+		if err != nil {
+			return err
+		}
+		c := Converter{
+			Logger: &ilog.NopLogger{},
+		}
+		out, err = c.ToHTML(raw, FileInfoToMeta(info))
+		return err
+	}
+	if _, err := Walk(src, "posts", fn); err != nil {
+		t.Fatalf("walk failed: %s", err)
+	}
+	got := string(out)
+	if !strings.Contains(got, "title: Hello World") {
+		t.Errorf("missing title in %s", got)
+	}
+	if !strings.Contains(got, "This is the first post.") {
+		t.Errorf("missing body in %s", got)
+	}
+}
+
+func TestWalkShortcuts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shortcuts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a/doc.html
+	// a/loop -> ..
+	// b/link.html -> ../a/doc.html
+	// b/folder -> ../a
+	os.MkdirAll(filepath.Join(dir, "a"), 0755)
+	os.MkdirAll(filepath.Join(dir, "b"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "a", "doc.html"), []byte("<p>doc</p>"), 0644)
+	for link, target := range map[string]string{
+		"a/loop":      "..",
+		"b/link.html": "../a/doc.html",
+		"b/folder":    "../a",
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skipf("unable to make symlink: %s", err)
+		}
+	}
+
+	var got []string
+	var loops []string
+	fn := func(src DriveSource, path string, info *drive.File, err error) error {
+		if err != nil {
+			loops = append(loops, path)
+			return nil
+		}
+		got = append(got, path)
+		if path == "b/link" {
+			if info.Id != "b/link.html" || TargetID(info) != "a/doc.html" {
+				t.Errorf("shortcut has id %q and target %q", info.Id, TargetID(info))
+			}
+			if raw, err := src.Export(info, "text/html"); err != nil || string(raw) != "<p>doc</p>" {
+				t.Errorf("unable to export shortcut: %q %v", raw, err)
+			}
+		}
+		return nil
+	}
+	if _, err := Walk(NewLocalSource(dir), "", fn); err != nil {
+		t.Fatalf("walk failed: %s", err)
+	}
+	want := []string{"", "a", "a/doc", "b", "b/folder", "b/folder/doc", "b/link"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+	wantLoops := []string{"a/loop", "b/folder/loop"}
+	if !reflect.DeepEqual(loops, wantLoops) {
+		t.Errorf("want loops %v got %v", wantLoops, loops)
+	}
+}
@@ -0,0 +1,185 @@
+package googledrive2hugo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// FileState is what was recorded about a file when it was last exported
+type FileState struct {
+	ModifiedTime string `json:"modifiedTime"`
+	Path         string `json:"path"`
+
+	// Draft is true if the file was not ready to publish, and was
+	// exported as a draft
+	Draft bool `json:"draft,omitempty"`
+
+	// Revision pins the file to a revision ID.  It is set by hand to
+	// publish that revision instead of the latest one.
+	Revision string `json:"revision,omitempty"`
+}
+
+// State is a manifest of previously exported files, keyed by Drive
+// file ID.  It's used to skip files that haven't changed since the
+// last run.
+type State struct {
+	Files map[string]*FileState `json:"files"`
+
+	// PageToken is where to start reading the Drive changes feed
+	// in watch mode
+	PageToken string `json:"pageToken,omitempty"`
+}
+
+// NewState returns an empty State
+func NewState() *State {
+	return &State{
+		Files: make(map[string]*FileState),
+	}
+}
+
+// LoadState reads a State from a file.  A missing file is not an
+// error and returns an empty State.
+func LoadState(filename string) (*State, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := NewState()
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, err
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]*FileState)
+	}
+	return s, nil
+}
+
+// Save writes the State to a file.  The file is replaced atomically
+// so an interrupted run never leaves a partial manifest.
+func (s *State) Save(filename string) error {
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filename, raw, 0644)
+}
+
+// Unchanged returns true if the file was exported to path and has not
+// been modified since
+func (s *State) Unchanged(f *drive.File, path string) bool {
+	prev, ok := s.Files[f.Id]
+	if !ok {
+		return false
+	}
+	return prev.ModifiedTime == f.ModifiedTime && prev.Path == path
+}
+
+// Update records that a file was exported to path.  A pinned
+// revision is kept.
+func (s *State) Update(f *drive.File, path string) {
+	fs := &FileState{
+		ModifiedTime: f.ModifiedTime,
+		Path:         path,
+	}
+	if prev, ok := s.Files[f.Id]; ok {
+		fs.Revision = prev.Revision
+	}
+	s.Files[f.Id] = fs
+}
+
+// Revision returns the revision a file is pinned to, or ""
+func (s *State) Revision(id string) string {
+	if prev, ok := s.Files[id]; ok {
+		return prev.Revision
+	}
+	return ""
+}
+
+// WriteFileAtomic writes to a temporary file in the same directory
+// then renames it into place, so readers never see a partial file
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Action is a change to previously exported output, as planned by
+// State.Plan
+type Action struct {
+	Op   string // ActionRename or ActionRemove
+	ID   string // Drive file ID
+	From string // current output path
+	To   string // new output path, only for ActionRename
+}
+
+const (
+	ActionRename = "rename"
+	ActionRemove = "remove"
+)
+
+func (a Action) String() string {
+	if a.Op == ActionRename {
+		return fmt.Sprintf("%s %s -> %s", a.Op, a.From, a.To)
+	}
+	return fmt.Sprintf("%s %s", a.Op, a.From)
+}
+
+// Plan compares the State to the files found in a walk, given as a map
+// of Drive file ID to output path.  Files that are no longer found
+// (trashed, deleted, or moved outside the walk) are removed, and
+// files that were renamed or moved between folders are renamed.
+//
+// Actions are sorted by current output path.
+func (s *State) Plan(seen map[string]string) []Action {
+	var out []Action
+	for id, prev := range s.Files {
+		path, ok := seen[id]
+		if !ok {
+			out = append(out, Action{Op: ActionRemove, ID: id, From: prev.Path})
+			continue
+		}
+		if path != prev.Path {
+			out = append(out, Action{Op: ActionRename, ID: id, From: prev.Path, To: path})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].From < out[j].From
+	})
+	return out
+}
+
+// Done records that an action was carried out
+func (s *State) Done(a Action) {
+	switch a.Op {
+	case ActionRemove:
+		delete(s.Files, a.ID)
+	case ActionRename:
+		if prev, ok := s.Files[a.ID]; ok {
+			prev.Path = a.To
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package googledrive2hugo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+)
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "state.json")
+
+	s, err := LoadState(filename)
+	if err != nil {
+		t.Fatalf("missing state file should not be an error: %s", err)
+	}
+	f := &drive.File{Id: "abc", ModifiedTime: "2018-10-01T00:00:00Z"}
+	if s.Unchanged(f, "post.html") {
+		t.Errorf("empty state says file is unchanged")
+	}
+	s.Update(f, "post.html")
+	if err := s.Save(filename); err != nil {
+		t.Fatalf("unable to save: %s", err)
+	}
+
+	s, err = LoadState(filename)
+	if err != nil {
+		t.Fatalf("unable to load: %s", err)
+	}
+	if !s.Unchanged(f, "post.html") {
+		t.Errorf("expected file to be unchanged")
+	}
+	if s.Unchanged(f, "renamed.html") {
+		t.Errorf("expected new path to be a change")
+	}
+	f.ModifiedTime = "2018-10-02T00:00:00Z"
+	if s.Unchanged(f, "post.html") {
+		t.Errorf("expected new modified time to be a change")
+	}
+}
+
+func TestStatePlan(t *testing.T) {
+	s := NewState()
+	s.Update(&drive.File{Id: "same"}, "out/same.html")
+	s.Update(&drive.File{Id: "gone"}, "out/gone.html")
+	s.Update(&drive.File{Id: "moved"}, "out/a/moved.html")
+
+	seen := map[string]string{
+		"same":  "out/same.html",
+		"moved": "out/b/moved.html",
+		"new":   "out/new.html",
+	}
+	actions := s.Plan(seen)
+	want := []Action{
+		{Op: ActionRename, ID: "moved", From: "out/a/moved.html", To: "out/b/moved.html"},
+		{Op: ActionRemove, ID: "gone", From: "out/gone.html"},
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Fatalf("want %v got %v", want, actions)
+	}
+	for _, a := range actions {
+		s.Done(a)
+	}
+	if _, ok := s.Files["gone"]; ok {
+		t.Errorf("removed file still in state")
+	}
+	if s.Files["moved"].Path != "out/b/moved.html" {
+		t.Errorf("renamed file has path %q", s.Files["moved"].Path)
+	}
+	if len(s.Plan(seen)) != 0 {
+		t.Errorf("expected nothing to do after actions are done")
+	}
+}
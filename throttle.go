@@ -0,0 +1,215 @@
+package googledrive2hugo
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// ThrottledSource wraps a DriveSource, limiting how fast calls are
+// made with a token bucket, and retrying calls with exponential
+// backoff when Drive reports a rate limit or a server error.
+//
+// It is safe for concurrent use if the wrapped source is.
+type ThrottledSource struct {
+	Source  DriveSource
+	Limiter *rate.Limiter
+
+	// Retries is the maximum number of retries for one call
+	Retries int
+
+	// Backoff is the delay before the first retry.  It doubles on
+	// each retry, with some jitter added.
+	Backoff time.Duration
+}
+
+// NewThrottledSource allows perSecond calls to src, with bursts of up
+// to burst calls
+func NewThrottledSource(src DriveSource, perSecond float64, burst int) *ThrottledSource {
+	return &ThrottledSource{
+		Source:  src,
+		Limiter: rate.NewLimiter(rate.Limit(perSecond), burst),
+		Retries: 5,
+		Backoff: time.Second,
+	}
+}
+
+// List is rate limited and retried per page if the wrapped source is a
+// Pager, so a retry doesn't start the listing over
+func (t *ThrottledSource) List(folder *drive.File) (files []*drive.File, err error) {
+	if pager, ok := t.Source.(Pager); ok {
+		return t.pages(func(token string) ([]*drive.File, string, error) {
+			return pager.ListPage(folder, token)
+		})
+	}
+	err = t.retry(func() error {
+		files, err = t.Source.List(folder)
+		return err
+	})
+	return files, err
+}
+
+func (t *ThrottledSource) Resolve(path string) (info *drive.File, err error) {
+	err = t.retry(func() error {
+		info, err = t.Source.Resolve(path)
+		return err
+	})
+	return info, err
+}
+
+func (t *ThrottledSource) Get(id string) (info *drive.File, err error) {
+	err = t.retry(func() error {
+		info, err = t.Source.Get(id)
+		return err
+	})
+	return info, err
+}
+
+// Search forwards to the wrapped source, if it is a Searcher.  Like
+// List, it goes page by page if the source is a Pager.
+func (t *ThrottledSource) Search(query string) (files []*drive.File, err error) {
+	if pager, ok := t.Source.(Pager); ok {
+		return t.pages(func(token string) ([]*drive.File, string, error) {
+			return pager.SearchPage(query, token)
+		})
+	}
+	searcher, ok := t.Source.(Searcher)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support queries", t.Source)
+	}
+	err = t.retry(func() error {
+		files, err = searcher.Search(query)
+		return err
+	})
+	return files, err
+}
+
+// Revisions forwards to the wrapped source, if it is a Reviser
+func (t *ThrottledSource) Revisions(f *drive.File) (revs []*drive.Revision, err error) {
+	reviser, ok := t.Source.(Reviser)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support revisions", t.Source)
+	}
+	err = t.retry(func() error {
+		revs, err = reviser.Revisions(f)
+		return err
+	})
+	return revs, err
+}
+
+// ExportRevision forwards to the wrapped source, if it is a Reviser
+func (t *ThrottledSource) ExportRevision(f *drive.File, revisionID string, mimeType string) (out []byte, err error) {
+	reviser, ok := t.Source.(Reviser)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support revisions", t.Source)
+	}
+	err = t.retry(func() error {
+		out, err = reviser.ExportRevision(f, revisionID, mimeType)
+		return err
+	})
+	return out, err
+}
+
+// ExportSlides forwards to the wrapped source, if it is a
+// SlideExporter
+func (t *ThrottledSource) ExportSlides(f *drive.File) (out [][]byte, err error) {
+	exporter, ok := t.Source.(SlideExporter)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support exporting slides", t.Source)
+	}
+	err = t.retry(func() error {
+		out, err = exporter.ExportSlides(f)
+		return err
+	})
+	return out, err
+}
+
+// StartPageToken forwards to the wrapped source, if it is a Changer
+func (t *ThrottledSource) StartPageToken() (token string, err error) {
+	changer, ok := t.Source.(Changer)
+	if !ok {
+		return "", fmt.Errorf("%T does not support changes", t.Source)
+	}
+	err = t.retry(func() error {
+		token, err = changer.StartPageToken()
+		return err
+	})
+	return token, err
+}
+
+// Changes forwards to the wrapped source, if it is a Changer
+func (t *ThrottledSource) Changes(token string) (changes []*drive.Change, next string, err error) {
+	changer, ok := t.Source.(Changer)
+	if !ok {
+		return nil, "", fmt.Errorf("%T does not support changes", t.Source)
+	}
+	err = t.retry(func() error {
+		changes, next, err = changer.Changes(token)
+		return err
+	})
+	return changes, next, err
+}
+
+func (t *ThrottledSource) Export(f *drive.File, mimeType string) (out []byte, err error) {
+	err = t.retry(func() error {
+		out, err = t.Source.Export(f, mimeType)
+		return err
+	})
+	return out, err
+}
+
+// pages gets every page, each one rate limited and retried on its own
+func (t *ThrottledSource) pages(page func(pageToken string) ([]*drive.File, string, error)) ([]*drive.File, error) {
+	return allPages(func(token string) (files []*drive.File, next string, err error) {
+		err = t.retry(func() error {
+			files, next, err = page(token)
+			return err
+		})
+		return files, next, err
+	})
+}
+
+func (t *ThrottledSource) retry(fn func() error) error {
+	delay := t.Backoff
+	for attempt := 0; ; attempt++ {
+		if err := t.Limiter.Wait(context.Background()); err != nil {
+			return err
+		}
+		err := fn()
+		if err == nil || attempt >= t.Retries || !IsRetryable(err) {
+			return err
+		}
+		jitter := time.Duration(rand.Int63n(int64(delay)/2 + 1))
+		time.Sleep(delay + jitter)
+		delay *= 2
+	}
+}
+
+// IsRetryable returns true if the error from Drive is a rate limit
+// (403 rateLimitExceeded or userRateLimitExceeded, or 429) or a
+// server error (5xx) that may succeed if tried later.
+func IsRetryable(err error) bool {
+	gerr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	switch {
+	case gerr.Code >= 500:
+		return true
+	case gerr.Code == http.StatusTooManyRequests:
+		return true
+	case gerr.Code == http.StatusForbidden:
+		for _, e := range gerr.Errors {
+			if e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package googledrive2hugo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// flakySource fails the first few exports
+type flakySource struct {
+	DriveSource
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakySource) Export(info *drive.File, mimeType string) ([]byte, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return []byte("ok"), nil
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("plain error"), false},
+		{&googleapi.Error{Code: 404}, false},
+		{&googleapi.Error{Code: 403}, false},
+		{&googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}, true},
+		{&googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}}}, true},
+		{&googleapi.Error{Code: 429}, true},
+		{&googleapi.Error{Code: 500}, true},
+		{&googleapi.Error{Code: 503}, true},
+	}
+	for i, tt := range cases {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("case %d: %v: want %v got %v", i, tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestThrottledSourceRetry(t *testing.T) {
+	flaky := &flakySource{
+		failures: 2,
+		err:      &googleapi.Error{Code: 503},
+	}
+	src := &ThrottledSource{
+		Source:  flaky,
+		Limiter: rate.NewLimiter(rate.Inf, 1),
+		Retries: 3,
+		Backoff: time.Millisecond,
+	}
+	out, err := src.Export(&drive.File{}, "text/html")
+	if err != nil {
+		t.Fatalf("expected retries to succeed: %s", err)
+	}
+	if string(out) != "ok" || flaky.calls != 3 {
+		t.Errorf("got %q after %d calls", out, flaky.calls)
+	}
+
+	// not retryable, fails immediately
+	flaky = &flakySource{
+		failures: 2,
+		err:      &googleapi.Error{Code: 404},
+	}
+	src.Source = flaky
+	if _, err := src.Export(&drive.File{}, "text/html"); err == nil {
+		t.Errorf("expected error")
+	}
+	if flaky.calls != 1 {
+		t.Errorf("expected 1 call, got %d", flaky.calls)
+	}
+}
+
+// pagedSource has three pages of one file, and fails the second page
+// the first time it is asked for
+type pagedSource struct {
+	DriveSource
+	calls  []string
+	failed bool
+}
+
+func (p *pagedSource) ListPage(folder *drive.File, token string) ([]*drive.File, string, error) {
+	p.calls = append(p.calls, token)
+	if token == "2" && !p.failed {
+		p.failed = true
+		return nil, "", &googleapi.Error{Code: 503}
+	}
+	next := map[string]string{"": "2", "2": "3", "3": ""}[token]
+	return []*drive.File{{Id: "file" + token}}, next, nil
+}
+
+func (p *pagedSource) SearchPage(query string, token string) ([]*drive.File, string, error) {
+	return p.ListPage(nil, token)
+}
+
+func TestThrottledSourcePages(t *testing.T) {
+	paged := &pagedSource{}
+	src := &ThrottledSource{
+		Source:  paged,
+		Limiter: rate.NewLimiter(rate.Inf, 1),
+		Retries: 3,
+		Backoff: time.Millisecond,
+	}
+	files, err := src.List(&drive.File{Id: "folder"})
+	if err != nil {
+		t.Fatalf("list failed: %s", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("expected 3 files, got %d", len(files))
+	}
+
+	// only the failed page is asked for again
+	want := []string{"", "2", "2", "3"}
+	if !reflect.DeepEqual(paged.calls, want) {
+		t.Errorf("want pages %q got %q", want, paged.calls)
+	}
+}